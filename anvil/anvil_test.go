@@ -0,0 +1,135 @@
+package anvil
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/emmanuelvlad/slime2schem/internal/testworld"
+	"github.com/emmanuelvlad/slime2schem/slime"
+)
+
+// testWorld returns the testworld overworld chunk and an empty chunk at
+// -1,3.
+func testWorld() *slime.SlimeWorld {
+	w := testworld.Overworld()
+	w.Chunks = append(w.Chunks, slime.Chunk{X: -1, Z: 3, Sections: []slime.Section{testworld.Air(), testworld.Air()}})
+	return w
+}
+
+func TestWriteReadRoundTrip(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "world")
+	want := testWorld()
+	if err := WriteWorld(want, dir, WriteOptions{MinY: DefaultMinY, LevelName: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadWorld(dir, ReadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.WorldVersion != want.WorldVersion || got.MinY != DefaultMinY {
+		t.Errorf("data version %d, min Y %d; want %d, %d", got.WorldVersion, got.MinY, want.WorldVersion, DefaultMinY)
+	}
+	chunks := make(map[[2]int32]*slime.Chunk)
+	for i := range got.Chunks {
+		c := &got.Chunks[i]
+		chunks[[2]int32{c.X, c.Z}] = c
+	}
+	if len(chunks) != 2 || chunks[[2]int32{0, 0}] == nil || chunks[[2]int32{-1, 3}] == nil {
+		t.Fatalf("read chunks %v, want 0,0 and -1,3", chunks)
+	}
+
+	c := chunks[[2]int32{0, 0}]
+	if len(c.Sections) < 2 {
+		t.Fatalf("%d sections, want at least 2", len(c.Sections))
+	}
+	for _, pos := range [][3]int{{0, 0, 0}, {15, 0, 15}} {
+		if b := c.Sections[1].GetBlockAt(pos[0], pos[1], pos[2]); b.Name != "minecraft:stone" {
+			t.Errorf("block at %v = %s, want minecraft:stone", pos, b.Name)
+		}
+	}
+	if b := c.Sections[1].GetBlockAt(0, 1, 0); b.Name != "minecraft:air" {
+		t.Errorf("block above the floor = %s, want minecraft:air", b.Name)
+	}
+	if b := c.Sections[0].GetBlockAt(0, 0, 0); b.Name != "minecraft:air" {
+		t.Errorf("block in the first section = %s, want minecraft:air", b.Name)
+	}
+	if len(c.TileEntities) != 1 || c.TileEntities[0]["id"] != "minecraft:chest" {
+		t.Errorf("block entities = %v, want one chest", c.TileEntities)
+	}
+	if len(c.Entities) != 1 || c.Entities[0]["id"] != "minecraft:pig" {
+		t.Errorf("entities = %v, want one pig", c.Entities)
+	}
+
+	skipped, err := ReadWorld(dir, ReadOptions{SkipEntities: true, Chunks: &ChunkRange{MinX: 0, MinZ: 0, MaxX: 0, MaxZ: 0}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(skipped.Chunks) != 1 || len(skipped.Chunks[0].Entities) != 0 {
+		t.Errorf("with options, read %d chunks and %d entities, want 1 and 0", len(skipped.Chunks), len(skipped.Chunks[0].Entities))
+	}
+}
+
+func TestWriteWorldRefusesExistingWorld(t *testing.T) {
+	dir := t.TempDir()
+	if err := WriteWorld(testWorld(), dir, WriteOptions{MinY: DefaultMinY}); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteWorld(testWorld(), dir, WriteOptions{MinY: DefaultMinY}); err == nil {
+		t.Error("second WriteWorld into the same directory succeeded")
+	}
+}
+
+func TestParseChunkRejectsPre118(t *testing.T) {
+	for name, root := range map[string]map[string]interface{}{
+		"1.17 data version": {"DataVersion": int32(2730), "Status": "full"},
+		"Level compound":    {"DataVersion": int32(MinDataVersion), "Level": map[string]interface{}{}},
+		"no data version":   {"Status": "full"},
+	} {
+		if _, _, _, err := parseChunk(root); err == nil || !strings.Contains(err.Error(), "older than 1.18") {
+			t.Errorf("%s: err = %v, want an upgrade error", name, err)
+		}
+	}
+}
+
+func TestParseChunkNegativeSections(t *testing.T) {
+	stone := map[string]interface{}{
+		"palette": []interface{}{map[string]interface{}{"Name": "minecraft:stone"}},
+	}
+	light := make([]byte, 2048)
+	root := map[string]interface{}{
+		"DataVersion": int32(testworld.DataVersion),
+		"Status":      "minecraft:full",
+		"xPos":        int32(-2),
+		"zPos":        int32(5),
+		"yPos":        int32(-4),
+		// Listed out of order, with a light-only section below the world and
+		// section -3 missing.
+		"sections": []interface{}{
+			map[string]interface{}{"Y": int8(-2), "block_states": stone},
+			map[string]interface{}{"Y": int8(-5), "SkyLight": light},
+			map[string]interface{}{"Y": int8(-4), "block_states": map[string]interface{}{
+				"palette": []interface{}{map[string]interface{}{"Name": "minecraft:air"}},
+			}},
+		},
+	}
+
+	chunk, _, ok, err := parseChunk(root)
+	if err != nil || !ok {
+		t.Fatalf("parseChunk = %v, %v", ok, err)
+	}
+	if chunk.X != -2 || chunk.Z != 5 {
+		t.Errorf("chunk at %d,%d, want -2,5", chunk.X, chunk.Z)
+	}
+	// Sections -4, -3 and -2, from yPos up; the light-only one is left out.
+	if len(chunk.Sections) != 3 {
+		t.Fatalf("%d sections, want 3", len(chunk.Sections))
+	}
+	if chunk.Sections[1].BlockPalette != nil {
+		t.Errorf("missing section -3 read as %+v, want an empty section", chunk.Sections[1])
+	}
+	if b := chunk.Sections[2].GetBlockAt(7, 7, 7); b.Name != "minecraft:stone" {
+		t.Errorf("block in section -2 = %s, want minecraft:stone", b.Name)
+	}
+}
@@ -0,0 +1,360 @@
+// Package anvil reads and writes vanilla Minecraft worlds stored in the
+// Anvil region format (region/*.mca, entities/*.mca).
+//
+// Worlds are loaded into the same slime.SlimeWorld model the slime reader
+// produces, so conversion, filtering and export work the same for both.
+// Only worlds saved by Minecraft 1.18 or newer are supported; open older
+// worlds in a recent version first to upgrade their chunks.
+package anvil
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/Tnze/go-mc/nbt"
+	"github.com/Tnze/go-mc/save/region"
+
+	"github.com/emmanuelvlad/slime2schem/slime"
+)
+
+// MinDataVersion is the oldest chunk data version the reader understands
+// (Minecraft 1.18, which moved section data out of the Level compound).
+const MinDataVersion = 2860
+
+// Chunk compression types used in region files.
+const (
+	compressionGzip     = 1
+	compressionZlib     = 2
+	compressionNone     = 3
+	compressionExternal = 0x80 // chunk stored in a separate c.<x>.<z>.mcc file
+)
+
+// ReadOptions controls which parts of a world are read.
+type ReadOptions struct {
+	// SkipEntities skips the entities/ directory.
+	SkipEntities bool
+	// Chunks, if set, limits reading to the chunks inside it.
+	Chunks *ChunkRange
+}
+
+// ChunkRange is an inclusive rectangle of chunk coordinates.
+type ChunkRange struct {
+	MinX, MinZ int32
+	MaxX, MaxZ int32
+}
+
+// Contains reports whether chunk (x, z) lies inside the range.
+func (r *ChunkRange) Contains(x, z int32) bool {
+	return x >= r.MinX && x <= r.MaxX && z >= r.MinZ && z <= r.MaxZ
+}
+
+// overlapsRegion reports whether any chunk of region (rx, rz) lies inside
+// the range.
+func (r *ChunkRange) overlapsRegion(rx, rz int) bool {
+	minX, minZ := int32(rx*32), int32(rz*32)
+	return minX <= r.MaxX && minX+31 >= r.MinX && minZ <= r.MaxZ && minZ+31 >= r.MinZ
+}
+
+// ReadWorld reads a world (or dimension) directory containing a region/
+// subdirectory and, if present, an entities/ subdirectory.
+//
+// Chunks that are not fully generated (Status other than full) are skipped.
+// Each chunk's sections are listed from its lowest section (yPos) upwards,
+// with empty sections filling any gaps, matching the slime layout.
+func ReadWorld(dir string, opts ReadOptions) (*slime.SlimeWorld, error) {
+	regionDir := filepath.Join(dir, "region")
+	regionFiles, err := filepath.Glob(filepath.Join(regionDir, "r.*.*.mca"))
+	if err != nil {
+		return nil, err
+	}
+	if len(regionFiles) == 0 {
+		return nil, fmt.Errorf("no region files found in %s", regionDir)
+	}
+	sort.Strings(regionFiles)
+
+	world := &slime.SlimeWorld{}
+	index := make(map[[2]int32]int)
+	for _, path := range regionFiles {
+		if !regionWanted(path, opts.Chunks) {
+			continue
+		}
+		err := forEachChunk(path, func(root map[string]interface{}) error {
+			chunk, dataVersion, ok, err := parseChunk(root)
+			if err != nil || !ok {
+				return err
+			}
+			if opts.Chunks != nil && !opts.Chunks.Contains(chunk.X, chunk.Z) {
+				return nil
+			}
+			if uint32(dataVersion) > world.WorldVersion {
+				world.WorldVersion = uint32(dataVersion)
+			}
+			// Every 1.18+ chunk stores the world's lowest section as yPos.
+			if yPos, ok := toInt32(root["yPos"]); ok && len(world.Chunks) == 0 {
+				world.MinY = int(yPos) * 16
+			}
+			index[[2]int32{chunk.X, chunk.Z}] = len(world.Chunks)
+			world.Chunks = append(world.Chunks, chunk)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+	}
+
+	if !opts.SkipEntities {
+		entityFiles, err := filepath.Glob(filepath.Join(dir, "entities", "r.*.*.mca"))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(entityFiles)
+		for _, path := range entityFiles {
+			if !regionWanted(path, opts.Chunks) {
+				continue
+			}
+			err := forEachChunk(path, func(root map[string]interface{}) error {
+				pos, ok := root["Position"].([]int32)
+				if !ok || len(pos) != 2 {
+					return fmt.Errorf("entity chunk without Position")
+				}
+				i, ok := index[[2]int32{pos[0], pos[1]}]
+				if !ok {
+					return nil // no terrain loaded for this chunk
+				}
+				world.Chunks[i].Entities = append(world.Chunks[i].Entities, compoundList(root["Entities"])...)
+				return nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("entities/%s: %w", filepath.Base(path), err)
+			}
+		}
+	}
+
+	sort.Slice(world.Chunks, func(i, j int) bool {
+		a, b := world.Chunks[i], world.Chunks[j]
+		if a.Z != b.Z {
+			return a.Z < b.Z
+		}
+		return a.X < b.X
+	})
+	return world, nil
+}
+
+// regionWanted reports whether a region file may hold chunks in r.
+func regionWanted(path string, r *ChunkRange) bool {
+	if r == nil {
+		return true
+	}
+	var rx, rz int
+	if _, err := fmt.Sscanf(filepath.Base(path), "r.%d.%d.mca", &rx, &rz); err != nil {
+		return true
+	}
+	return r.overlapsRegion(rx, rz)
+}
+
+// forEachChunk decodes every chunk stored in a region file.
+func forEachChunk(path string, fn func(root map[string]interface{}) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// An empty region file (allocated but never written) has no header.
+	if info, err := f.Stat(); err == nil && info.Size() == 0 {
+		return nil
+	}
+
+	r, err := region.Load(f)
+	if err != nil {
+		return fmt.Errorf("reading region header: %w", err)
+	}
+	for z := 0; z < 32; z++ {
+		for x := 0; x < 32; x++ {
+			if !r.ExistSector(x, z) {
+				continue
+			}
+			data, err := r.ReadSector(x, z)
+			if errors.Is(err, region.ErrNoData) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("chunk %d,%d: %w", x, z, err)
+			}
+			root, err := decodeChunk(path, x, z, data)
+			if err != nil {
+				return fmt.Errorf("chunk %d,%d: %w", x, z, err)
+			}
+			if err := fn(root); err != nil {
+				return fmt.Errorf("chunk %d,%d: %w", x, z, err)
+			}
+		}
+	}
+	return nil
+}
+
+// decodeChunk decompresses and decodes the NBT of one region sector. x and
+// z are the chunk's position within the region, used to find oversized
+// chunks stored in external .mcc files.
+func decodeChunk(regionPath string, x, z int, data []byte) (map[string]interface{}, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty chunk data")
+	}
+	compression := data[0]
+	payload := data[1:]
+
+	if compression&compressionExternal != 0 {
+		compression &^= compressionExternal
+		var rx, rz int
+		if _, err := fmt.Sscanf(filepath.Base(regionPath), "r.%d.%d.mca", &rx, &rz); err != nil {
+			return nil, fmt.Errorf("parsing region file name: %w", err)
+		}
+		name := fmt.Sprintf("c.%d.%d.mcc", rx*32+x, rz*32+z)
+		var err error
+		payload, err = os.ReadFile(filepath.Join(filepath.Dir(regionPath), name))
+		if err != nil {
+			return nil, fmt.Errorf("reading external chunk: %w", err)
+		}
+	}
+
+	var r io.Reader = bytes.NewReader(payload)
+	switch compression {
+	case compressionGzip:
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		r = gz
+	case compressionZlib:
+		zr, err := zlib.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		r = zr
+	case compressionNone:
+	default:
+		return nil, fmt.Errorf("unsupported chunk compression type %d", compression)
+	}
+
+	var root map[string]interface{}
+	if _, err := nbt.NewDecoder(r).Decode(&root); err != nil {
+		return nil, fmt.Errorf("decoding chunk NBT: %w", err)
+	}
+	return root, nil
+}
+
+// parseChunk converts a decoded chunk into the slime model. ok is false for
+// chunks that are not fully generated.
+func parseChunk(root map[string]interface{}) (chunk slime.Chunk, dataVersion int32, ok bool, err error) {
+	dataVersion, _ = root["DataVersion"].(int32)
+	if _, legacy := root["Level"]; legacy || dataVersion < MinDataVersion {
+		return chunk, 0, false, fmt.Errorf("chunk data version %d is older than 1.18 (%d); open the world in a newer version to upgrade it", dataVersion, MinDataVersion)
+	}
+	if status, _ := root["Status"].(string); status != "minecraft:full" && status != "full" {
+		return chunk, 0, false, nil
+	}
+
+	chunk.X, _ = root["xPos"].(int32)
+	chunk.Z, _ = root["zPos"].(int32)
+
+	// The section range comes from yPos and the sections holding blocks;
+	// vanilla also stores light-only sections one below and one above the
+	// world, which are left out.
+	sections := compoundList(root["sections"])
+	yPos, hasYPos := toInt32(root["yPos"])
+	minY, maxY := int32(1<<31-1), int32(-1<<31)
+	byY := make(map[int32]map[string]interface{}, len(sections))
+	for _, s := range sections {
+		y, ok := toInt32(s["Y"])
+		if !ok {
+			continue
+		}
+		byY[y] = s
+		if _, hasBlocks := s["block_states"]; hasBlocks {
+			minY, maxY = min(minY, y), max(maxY, y)
+		}
+	}
+	if hasYPos {
+		minY = yPos
+	}
+	for y := minY; y <= maxY; y++ {
+		section, err := parseSection(byY[y])
+		if err != nil {
+			return chunk, 0, false, fmt.Errorf("section %d: %w", y, err)
+		}
+		chunk.Sections = append(chunk.Sections, section)
+	}
+
+	chunk.Heightmaps, _ = root["Heightmaps"].(map[string]interface{})
+	if pdc, ok := root["ChunkBukkitValues"]; ok {
+		chunk.Extra = map[string]interface{}{"ChunkBukkitValues": pdc}
+	}
+	chunk.TileEntities = compoundList(root["block_entities"])
+	chunk.BlockTicks = compoundList(root["block_ticks"])
+	chunk.FluidTicks = compoundList(root["fluid_ticks"])
+	return chunk, dataVersion, true, nil
+}
+
+func parseSection(s map[string]interface{}) (slime.Section, error) {
+	blockLight, _ := s["BlockLight"].([]byte)
+	skyLight, _ := s["SkyLight"].([]byte)
+	biomes, _ := s["biomes"].(map[string]interface{})
+	states, ok := s["block_states"].(map[string]interface{})
+	if !ok {
+		return slime.Section{Biomes: biomes, BlockLight: blockLight, SkyLight: skyLight}, nil
+	}
+	var palette []slime.BlockState
+	for _, entry := range compoundList(states["palette"]) {
+		name, _ := entry["Name"].(string)
+		if name == "" {
+			return slime.Section{}, fmt.Errorf("palette entry without Name")
+		}
+		var props map[string]string
+		if raw, ok := entry["Properties"].(map[string]interface{}); ok {
+			props = make(map[string]string, len(raw))
+			for k, v := range raw {
+				props[k], _ = v.(string)
+			}
+		}
+		palette = append(palette, slime.BlockState{Name: name, Properties: props})
+	}
+	data, _ := states["data"].([]int64)
+	section := slime.NewSection(palette, data)
+	section.Biomes = biomes
+	section.BlockLight = blockLight
+	section.SkyLight = skyLight
+	return section, nil
+}
+
+// compoundList returns the compound elements of an NBT list.
+func compoundList(v interface{}) []map[string]interface{} {
+	list, _ := v.([]interface{})
+	var out []map[string]interface{}
+	for _, item := range list {
+		if m, ok := item.(map[string]interface{}); ok {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+func toInt32(v interface{}) (int32, bool) {
+	switch n := v.(type) {
+	case int8:
+		return int32(n), true
+	case int16:
+		return int32(n), true
+	case int32:
+		return n, true
+	default:
+		return 0, false
+	}
+}
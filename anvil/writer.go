@@ -0,0 +1,347 @@
+package anvil
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/Tnze/go-mc/save/region"
+
+	"github.com/emmanuelvlad/slime2schem/internal/nbtenc"
+	"github.com/emmanuelvlad/slime2schem/slime"
+)
+
+// DefaultMinY is the bottom of the overworld since Minecraft 1.18.
+const DefaultMinY = -64
+
+// WriteOptions controls how a world is written.
+type WriteOptions struct {
+	// MinY is the block Y of each chunk's first section, a multiple of 16:
+	// DefaultMinY for the overworld, 0 for the nether and the end. Slime
+	// worlds do not record it.
+	MinY int
+	// LevelName is the world name shown in the world list.
+	LevelName string
+}
+
+// WriteWorld writes world as a vanilla world directory: region/*.mca,
+// entities/*.mca and a level.dat for a void superflat world, so the game
+// does not generate terrain around the imported chunks. Chunks are stored
+// with their lighting marked stale so the game recomputes it on load.
+//
+// dir is created if needed; it must not already contain a level.dat.
+func WriteWorld(world *slime.SlimeWorld, dir string, opts WriteOptions) error {
+	if opts.MinY%16 != 0 {
+		return fmt.Errorf("min Y %d is not a multiple of 16", opts.MinY)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "level.dat")); err == nil {
+		return fmt.Errorf("%s already contains a world", dir)
+	}
+	for _, sub := range []string{"region", "entities"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return err
+		}
+	}
+
+	regions := make(map[[2]int][]*slime.Chunk)
+	for i := range world.Chunks {
+		c := &world.Chunks[i]
+		rx, rz := region.At(int(c.X), int(c.Z))
+		regions[[2]int{rx, rz}] = append(regions[[2]int{rx, rz}], c)
+	}
+	keys := make([][2]int, 0, len(regions))
+	for k := range regions {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][1] != keys[j][1] {
+			return keys[i][1] < keys[j][1]
+		}
+		return keys[i][0] < keys[j][0]
+	})
+
+	dataVersion := int32(world.WorldVersion)
+	minSection := int32(opts.MinY / 16)
+	for _, k := range keys {
+		name := fmt.Sprintf("r.%d.%d.mca", k[0], k[1])
+		chunks := regions[k]
+
+		err := writeRegion(filepath.Join(dir, "region", name), chunks, func(c *slime.Chunk) (map[string]interface{}, bool) {
+			return chunkNBT(c, dataVersion, minSection), true
+		})
+		if err != nil {
+			return fmt.Errorf("region/%s: %w", name, err)
+		}
+
+		err = writeRegion(filepath.Join(dir, "entities", name), chunks, func(c *slime.Chunk) (map[string]interface{}, bool) {
+			if len(c.Entities) == 0 {
+				return nil, false
+			}
+			return map[string]interface{}{
+				"DataVersion": dataVersion,
+				"Position":    []int32{c.X, c.Z},
+				"Entities":    compounds(c.Entities),
+			}, true
+		})
+		if err != nil {
+			return fmt.Errorf("entities/%s: %w", name, err)
+		}
+	}
+
+	return writeLevelDat(filepath.Join(dir, "level.dat"), world, opts)
+}
+
+// writeRegion writes one region file with the NBT build returns for each
+// chunk. Chunks for which build returns false are left out, and no file is
+// written if that leaves the region empty.
+func writeRegion(path string, chunks []*slime.Chunk, build func(*slime.Chunk) (map[string]interface{}, bool)) error {
+	var r *region.Region
+	for _, c := range chunks {
+		root, ok := build(c)
+		if !ok {
+			continue
+		}
+		data, err := encodeChunk(root)
+		if err != nil {
+			return fmt.Errorf("chunk %d,%d: %w", c.X, c.Z, err)
+		}
+		if r == nil {
+			if r, err = region.Create(path); err != nil {
+				return err
+			}
+			defer r.Close()
+		}
+
+		x, z := region.In(int(c.X), int(c.Z))
+		err = r.WriteSector(x, z, data)
+		if errors.Is(err, region.ErrTooLarge) {
+			// Oversized chunks live in c.<x>.<z>.mcc next to the region
+			// file; the sector only keeps the compression type.
+			mcc := filepath.Join(filepath.Dir(path), fmt.Sprintf("c.%d.%d.mcc", c.X, c.Z))
+			if err := os.WriteFile(mcc, data[1:], 0644); err != nil {
+				return err
+			}
+			err = r.WriteSector(x, z, []byte{data[0] | compressionExternal})
+		}
+		if err != nil {
+			return fmt.Errorf("chunk %d,%d: %w", c.X, c.Z, err)
+		}
+	}
+	if r == nil {
+		return nil
+	}
+	return r.PadToFullSector()
+}
+
+// encodeChunk encodes chunk NBT as a zlib-compressed region sector payload.
+func encodeChunk(root map[string]interface{}) ([]byte, error) {
+	raw, err := nbtenc.Marshal(root)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.WriteByte(compressionZlib)
+	zw := zlib.NewWriter(&buf)
+	if _, err := zw.Write(raw); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func chunkNBT(c *slime.Chunk, dataVersion, minSection int32) map[string]interface{} {
+	sections := make([]interface{}, 0, len(c.Sections))
+	for i := range c.Sections {
+		sections = append(sections, sectionNBT(&c.Sections[i], int8(minSection+int32(i))))
+	}
+
+	root := map[string]interface{}{
+		"DataVersion":    dataVersion,
+		"xPos":           c.X,
+		"zPos":           c.Z,
+		"yPos":           minSection,
+		"Status":         "minecraft:full",
+		"LastUpdate":     int64(0),
+		"InhabitedTime":  int64(0),
+		"isLightOn":      int8(0),
+		"sections":       sections,
+		"block_entities": compounds(c.TileEntities),
+		"block_ticks":    compounds(c.BlockTicks),
+		"fluid_ticks":    compounds(c.FluidTicks),
+		"PostProcessing": []interface{}{},
+		"structures": map[string]interface{}{
+			"References": map[string]interface{}{},
+			"starts":     map[string]interface{}{},
+		},
+	}
+	if len(c.Heightmaps) > 0 {
+		root["Heightmaps"] = c.Heightmaps
+	}
+	if pdc, ok := c.Extra["ChunkBukkitValues"]; ok {
+		root["ChunkBukkitValues"] = pdc
+	}
+	return root
+}
+
+func sectionNBT(s *slime.Section, y int8) map[string]interface{} {
+	palette := make([]interface{}, 0, len(s.BlockPalette))
+	for _, bs := range s.BlockPalette {
+		entry := map[string]interface{}{"Name": bs.Name}
+		if len(bs.Properties) > 0 {
+			entry["Properties"] = bs.Properties
+		}
+		palette = append(palette, entry)
+	}
+	if len(palette) == 0 {
+		palette = append(palette, map[string]interface{}{"Name": "minecraft:air"})
+	}
+	blockStates := map[string]interface{}{"palette": palette}
+	if len(palette) > 1 && len(s.BlockStates) > 0 {
+		blockStates["data"] = s.BlockStates
+	}
+
+	biomes := s.Biomes
+	if len(biomes) == 0 {
+		biomes = map[string]interface{}{"palette": []interface{}{"minecraft:plains"}}
+	}
+
+	nbt := map[string]interface{}{
+		"Y":            y,
+		"block_states": blockStates,
+		"biomes":       biomes,
+	}
+	if len(s.BlockLight) == 2048 {
+		nbt["BlockLight"] = s.BlockLight
+	}
+	if len(s.SkyLight) == 2048 {
+		nbt["SkyLight"] = s.SkyLight
+	}
+	return nbt
+}
+
+// writeLevelDat writes a minimal level.dat: creative mode, cheats on, time
+// and weather frozen, and a void superflat overworld.
+func writeLevelDat(path string, world *slime.SlimeWorld, opts WriteOptions) error {
+	name := opts.LevelName
+	if name == "" {
+		name = "Imported world"
+	}
+	spawn := spawnPoint(world, opts.MinY)
+
+	data := map[string]interface{}{
+		"DataVersion":   int32(world.WorldVersion),
+		"version":       int32(19133), // Anvil
+		"LevelName":     name,
+		"GameType":      int32(1),
+		"allowCommands": int8(1),
+		"Difficulty":    int8(0),
+		"hardcore":      int8(0),
+		"initialized":   int8(1),
+		"LastPlayed":    int64(0),
+		"Time":          int64(0),
+		"DayTime":       int64(6000),
+		"SpawnX":        spawn[0],
+		"SpawnY":        spawn[1],
+		"SpawnZ":        spawn[2],
+		"GameRules": map[string]interface{}{
+			"doDaylightCycle": "false",
+			"doWeatherCycle":  "false",
+			"doMobSpawning":   "false",
+		},
+		"DataPacks": map[string]interface{}{
+			"Enabled":  []interface{}{"vanilla"},
+			"Disabled": []interface{}{},
+		},
+		"WorldGenSettings": map[string]interface{}{
+			"seed":              int64(0),
+			"generate_features": int8(0),
+			"bonus_chest":       int8(0),
+			"dimensions": map[string]interface{}{
+				"minecraft:overworld": map[string]interface{}{
+					"type": "minecraft:overworld",
+					"generator": map[string]interface{}{
+						"type": "minecraft:flat",
+						"settings": map[string]interface{}{
+							"biome":               "minecraft:the_void",
+							"features":            int8(0),
+							"lakes":               int8(0),
+							"layers":              []interface{}{},
+							"structure_overrides": []interface{}{},
+						},
+					},
+				},
+				"minecraft:the_nether": map[string]interface{}{
+					"type": "minecraft:the_nether",
+					"generator": map[string]interface{}{
+						"type":     "minecraft:noise",
+						"settings": "minecraft:nether",
+						"biome_source": map[string]interface{}{
+							"type":   "minecraft:multi_noise",
+							"preset": "minecraft:nether",
+						},
+					},
+				},
+				"minecraft:the_end": map[string]interface{}{
+					"type": "minecraft:the_end",
+					"generator": map[string]interface{}{
+						"type":         "minecraft:noise",
+						"settings":     "minecraft:end",
+						"biome_source": map[string]interface{}{"type": "minecraft:the_end"},
+					},
+				},
+			},
+		},
+	}
+
+	raw, err := nbtenc.Marshal(map[string]interface{}{"Data": data})
+	if err != nil {
+		return fmt.Errorf("encoding level.dat: %w", err)
+	}
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(raw); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// spawnPoint returns the center of the world's chunks, on top of its
+// highest section.
+func spawnPoint(world *slime.SlimeWorld, minY int) [3]int32 {
+	if len(world.Chunks) == 0 {
+		return [3]int32{0, int32(minY), 0}
+	}
+	minX, maxX := int32(math.MaxInt32), int32(math.MinInt32)
+	minZ, maxZ := int32(math.MaxInt32), int32(math.MinInt32)
+	sections := 0
+	for _, c := range world.Chunks {
+		minX, maxX = min(minX, c.X), max(maxX, c.X)
+		minZ, maxZ = min(minZ, c.Z), max(maxZ, c.Z)
+		sections = max(sections, len(c.Sections))
+	}
+	return [3]int32{
+		(minX + maxX + 1) * 8,
+		int32(minY + sections*16),
+		(minZ + maxZ + 1) * 8,
+	}
+}
+
+// compounds converts a compound slice to a generic NBT list.
+func compounds(list []map[string]interface{}) []interface{} {
+	out := make([]interface{}, len(list))
+	for i, m := range list {
+		out[i] = m
+	}
+	return out
+}
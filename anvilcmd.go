@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/emmanuelvlad/slime2schem/anvil"
+)
+
+// runAnvil implements "slime2schem anvil".
+func runAnvil(args []string) int {
+	fs := flag.NewFlagSet("anvil", flag.ExitOnError)
+	minY := fs.Int("min-y", anvil.DefaultMinY, "Block Y of the lowest section: -64 for the overworld, 0 for the nether and end")
+	name := fs.String("name", "", "World name shown in the world list (default: input file name)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: slime2schem anvil [-min-y Y] [-name NAME] <world.slime> <output dir>\n")
+		fmt.Fprintf(os.Stderr, "\nWrites a slime world as a vanilla world directory that can be opened in single-player.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	world, err := readWorld(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFailure
+	}
+
+	if *name == "" {
+		input := filepath.Base(filepath.Clean(fs.Arg(0)))
+		*name = strings.TrimSuffix(input, filepath.Ext(input))
+	}
+
+	outDir := fs.Arg(1)
+	if err := anvil.WriteWorld(world, outDir, anvil.WriteOptions{MinY: *minY, LevelName: *name}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing world: %v\n", err)
+		return exitFailure
+	}
+	fmt.Printf("Wrote %d chunks (data version %d) to %s\n", len(world.Chunks), world.WorldVersion, outDir)
+	return exitOK
+}
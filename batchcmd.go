@@ -0,0 +1,352 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// batchJob is one world to convert in a batch.
+type batchJob struct {
+	name   string // shown in messages: the file path, or archive/entry
+	output string
+	read   func() ([]byte, error)
+}
+
+// batchResult is the outcome of one job, or of an input that could not be
+// read at all.
+type batchResult struct {
+	name    string
+	output  string
+	err     error
+	elapsed time.Duration
+}
+
+// runBatch implements "slime2schem batch".
+func runBatch(args []string) int {
+	flags := flag.NewFlagSet("batch", flag.ExitOnError)
+	outDir := flags.String("out", "", "Directory to write the converted worlds to, mirroring the input folders (required)")
+	workers := flags.Int("j", runtime.NumCPU(), "Number of worlds to convert at once; each needs memory for its whole schematic")
+	var opts convertOptions
+	opts.register(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: slime2schem batch -out DIR [-j N] [convert flags] <dir|glob|archive|world>...\n")
+		fmt.Fprintf(os.Stderr, "\nConverts every .slime and .polar file in directories (recursively), glob matches\n")
+		fmt.Fprintf(os.Stderr, "and .zip/.tar.gz archives, keeping going when one fails.\n\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 || *outDir == "" || *workers < 1 {
+		flags.Usage()
+		return exitUsage
+	}
+	if err := opts.parse(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitUsage
+	}
+
+	b := &batch{outDir: *outDir, ext: opts.format.ext, jobs: make(chan batchJob), results: make(chan batchResult), outputs: make(map[string]string)}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for _, arg := range flags.Args() {
+			b.addArg(arg)
+		}
+		close(b.jobs)
+	}()
+	for i := 0; i < *workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range b.jobs {
+				b.results <- runBatchJob(job, &opts)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(b.results)
+	}()
+
+	start := time.Now()
+	var failed []batchResult
+	total := 0
+	for r := range b.results {
+		total++
+		if r.err != nil {
+			failed = append(failed, r)
+			fmt.Printf("FAIL  %s: %v\n", r.name, r.err)
+			continue
+		}
+		fmt.Printf("ok    %s -> %s (%.1fs)\n", r.name, r.output, r.elapsed.Seconds())
+	}
+	b.closeArchives()
+
+	fmt.Printf("\nConverted %d of %d worlds in %.1fs\n", total-len(failed), total, time.Since(start).Seconds())
+	if total == 0 {
+		fmt.Fprintf(os.Stderr, "Error: no .slime or .polar files found\n")
+		return exitFailure
+	}
+	if len(failed) > 0 {
+		sort.Slice(failed, func(i, j int) bool { return failed[i].name < failed[j].name })
+		fmt.Printf("%d failed:\n", len(failed))
+		for _, r := range failed {
+			fmt.Printf("  %s: %v\n", r.name, r.err)
+		}
+		return exitFailure
+	}
+	return exitOK
+}
+
+func runBatchJob(job batchJob, opts *convertOptions) batchResult {
+	start := time.Now()
+	result := batchResult{name: job.name, output: job.output}
+	result.err = func() error {
+		data, err := job.read()
+		if err != nil {
+			return err
+		}
+		world, err := parseWorld(job.name, data)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(job.output), 0755); err != nil {
+			return err
+		}
+		// Jobs run concurrently, so their progress lines would interleave;
+		// each job prints one line when it finishes instead.
+		return convertWorld(world, job.output, "", opts, io.Discard)
+	}()
+	result.elapsed = time.Since(start)
+	return result
+}
+
+// batch finds the worlds to convert and queues them. Its methods run on a
+// single goroutine.
+type batch struct {
+	outDir  string
+	ext     string // output extension
+	jobs    chan batchJob
+	results chan batchResult
+	// outputs maps each output path to the input it was claimed by, so two
+	// inputs cannot overwrite each other (e.g. a.slime and a.polar).
+	outputs  map[string]string
+	archives []io.Closer
+}
+
+// addArg queues the worlds named by a command-line argument: a world file,
+// an archive, a directory or a glob pattern. Output paths are relative to
+// the directory the argument names, or that holds it.
+func (b *batch) addArg(arg string) {
+	if !strings.ContainsAny(arg, "*?[") {
+		root := arg
+		if info, err := os.Stat(arg); err == nil && !info.IsDir() {
+			root = filepath.Dir(arg)
+		}
+		b.addPath(arg, root)
+		return
+	}
+
+	matches, err := filepath.Glob(arg)
+	if err == nil && len(matches) == 0 {
+		err = errors.New("no files match")
+	}
+	if err != nil {
+		b.fail(arg, err)
+		return
+	}
+	root := globRoot(arg)
+	for _, m := range matches {
+		b.addPath(m, root)
+	}
+}
+
+func (b *batch) addPath(p, root string) {
+	info, err := os.Stat(p)
+	if err != nil {
+		b.fail(p, err)
+		return
+	}
+	switch {
+	case info.IsDir():
+		err := filepath.WalkDir(p, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				b.fail(file, err)
+				return nil
+			}
+			switch {
+			case d.IsDir():
+			case isArchive(file):
+				b.addArchive(file, root)
+			case isWorldFile(file):
+				b.addFile(file, root)
+			}
+			return nil
+		})
+		if err != nil {
+			b.fail(p, err)
+		}
+	case isArchive(p):
+		b.addArchive(p, root)
+	default:
+		b.addFile(p, root)
+	}
+}
+
+func (b *batch) addFile(file, root string) {
+	b.queue(file, relPath(root, file), func() ([]byte, error) {
+		return os.ReadFile(file)
+	})
+}
+
+// addArchive queues the worlds in a .zip or .tar.gz archive. Their output
+// paths are under a folder named after the archive.
+func (b *batch) addArchive(file, root string) {
+	base := relPath(root, file)
+	base = base[:len(base)-len(archiveExt(base))]
+
+	if archiveExt(file) == ".zip" {
+		zr, err := zip.OpenReader(file)
+		if err != nil {
+			b.fail(file, err)
+			return
+		}
+		b.archives = append(b.archives, zr)
+		for _, f := range zr.File {
+			if f.FileInfo().IsDir() || !isWorldFile(f.Name) {
+				continue
+			}
+			b.queue(file+"/"+f.Name, filepath.Join(base, entryPath(f.Name)), func() ([]byte, error) {
+				rc, err := f.Open()
+				if err != nil {
+					return nil, err
+				}
+				defer rc.Close()
+				return io.ReadAll(rc)
+			})
+		}
+		return
+	}
+
+	// Tar archives can only be read in order, so entries are read here;
+	// queueing blocks until a worker is free, which bounds the memory held.
+	f, err := os.Open(file)
+	if err != nil {
+		b.fail(file, err)
+		return
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		b.fail(file, err)
+		return
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			b.fail(file, err)
+			return
+		}
+		if hdr.Typeflag != tar.TypeReg || !isWorldFile(hdr.Name) {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		b.queue(file+"/"+hdr.Name, filepath.Join(base, entryPath(hdr.Name)), func() ([]byte, error) {
+			return data, err
+		})
+	}
+}
+
+// queue adds a job writing the world at rel (relative to the output
+// directory, with its input extension).
+func (b *batch) queue(name, rel string, read func() ([]byte, error)) {
+	output := filepath.Join(b.outDir, strings.TrimSuffix(rel, filepath.Ext(rel))+b.ext)
+	if other, ok := b.outputs[output]; ok {
+		if other == name {
+			return // the same input given twice
+		}
+		b.results <- batchResult{name: name, output: output, err: fmt.Errorf("%s is also the output of %s", output, other)}
+		return
+	}
+	b.outputs[output] = name
+	b.jobs <- batchJob{name: name, output: output, read: read}
+}
+
+func (b *batch) fail(name string, err error) {
+	b.results <- batchResult{name: name, err: err}
+}
+
+func (b *batch) closeArchives() {
+	for _, a := range b.archives {
+		a.Close()
+	}
+}
+
+func isWorldFile(name string) bool {
+	switch strings.ToLower(path.Ext(filepath.ToSlash(name))) {
+	case ".slime", ".polar":
+		return true
+	}
+	return false
+}
+
+func isArchive(name string) bool {
+	return archiveExt(name) != ""
+}
+
+// archiveExt returns the archive extension of name (".zip", ".tar.gz" or
+// ".tgz"), or "" if it is not an archive.
+func archiveExt(name string) string {
+	lower := strings.ToLower(name)
+	for _, ext := range []string{".zip", ".tar.gz", ".tgz"} {
+		if strings.HasSuffix(lower, ext) {
+			return name[len(name)-len(ext):]
+		}
+	}
+	return ""
+}
+
+// relPath returns file relative to root, or its base name if it is not
+// under root.
+func relPath(root, file string) string {
+	rel, err := filepath.Rel(root, file)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.Base(file)
+	}
+	return rel
+}
+
+// entryPath turns an archive entry name into a relative path that cannot
+// escape the output directory.
+func entryPath(name string) string {
+	return filepath.FromSlash(strings.TrimPrefix(path.Clean("/"+name), "/"))
+}
+
+// globRoot returns the directory part of a glob pattern that comes before
+// the first wildcard.
+func globRoot(pattern string) string {
+	dir := pattern
+	for strings.ContainsAny(dir, "*?[") {
+		dir = filepath.Dir(dir)
+	}
+	return dir
+}
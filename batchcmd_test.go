@@ -0,0 +1,113 @@
+package main
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/emmanuelvlad/slime2schem/internal/testworld"
+	"github.com/emmanuelvlad/slime2schem/slime"
+)
+
+// captureStdout returns what f prints to stdout.
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	out := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		out <- string(data)
+	}()
+	defer func() { os.Stdout = stdout }()
+	f()
+	w.Close()
+	return <-out
+}
+
+func TestBatch(t *testing.T) {
+	world, err := slime.WriteSlimeWorld(testworld.Overworld())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in")
+	files := map[string][]byte{
+		"a.polar":      []byte("not a world"),
+		"a.slime":      world, // same output as a.polar, which is walked first
+		"broken.slime": []byte("not a world"),
+		"sub/b.slime":  world,
+		"notes.txt":    []byte("skipped"),
+	}
+	for name, data := range files {
+		p := filepath.Join(in, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	zf, err := os.Create(filepath.Join(in, "pack.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(zf)
+	for _, name := range []string{"c.slime", "../escape.slime"} {
+		fw, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write(world)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zf.Close()
+
+	out := filepath.Join(dir, "out")
+	var code int
+	stdout := captureStdout(t, func() {
+		code = runBatch([]string{"-out", out, "-j", "3", in})
+	})
+	if code != exitFailure {
+		t.Errorf("exit code %d, want %d", code, exitFailure)
+	}
+
+	for _, want := range []string{"sub/b.schem", "pack/c.schem", "pack/escape.schem"} {
+		if _, err := os.Stat(filepath.Join(out, want)); err != nil {
+			t.Errorf("missing output: %v", err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(out, "a.schem")); err == nil {
+		t.Error("a.schem written although both of its inputs failed")
+	}
+
+	// Results arrive in whatever order the workers finish, but the failures
+	// are listed again at the end, sorted.
+	if !strings.Contains(stdout, "Converted 3 of 6 worlds") {
+		t.Errorf("summary missing from output:\n%s", stdout)
+	}
+	_, summary, ok := strings.Cut(stdout, "3 failed:\n")
+	if !ok {
+		t.Fatalf("failure list missing from output:\n%s", stdout)
+	}
+	var names []string
+	for _, line := range strings.Split(strings.TrimSpace(summary), "\n") {
+		name, _, _ := strings.Cut(strings.TrimSpace(line), ": ")
+		names = append(names, filepath.Base(name))
+	}
+	if want := []string{"a.polar", "a.slime", "broken.slime"}; strings.Join(names, " ") != strings.Join(want, " ") {
+		t.Errorf("failures listed as %v, want %v", names, want)
+	}
+	if !strings.Contains(summary, "is also the output of") {
+		t.Errorf("a.slime not reported as clashing with a.polar:\n%s", summary)
+	}
+}
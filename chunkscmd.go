@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/emmanuelvlad/slime2schem/anvil"
+	"github.com/emmanuelvlad/slime2schem/slime"
+)
+
+// chunkSummary is one line of "slime2schem chunks".
+type chunkSummary struct {
+	X             int32 `json:"x"`
+	Z             int32 `json:"z"`
+	Sections      int   `json:"sections"`
+	BlockSections int   `json:"blockSections"` // sections that are not all air
+	BlockEntities int   `json:"blockEntities"`
+	Entities      int   `json:"entities"`
+	Size          int   `json:"size,omitempty"` // uncompressed bytes, slime files only
+}
+
+// runChunks implements "slime2schem chunks".
+func runChunks(args []string) int {
+	fs := flag.NewFlagSet("chunks", flag.ExitOnError)
+	jsonOut := fs.Bool("json", false, "Print the chunk list as JSON")
+	chunks := fs.String("chunks", "", "Only list chunks in the inclusive range x1,z1:x2,z2 (chunk coordinates)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: slime2schem chunks [-json] [-chunks x1,z1:x2,z2] <world.slime>\n")
+		fmt.Fprintf(os.Stderr, "\nLists a world's chunks with their section, block entity and entity counts.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	var opts anvil.ReadOptions
+	if *chunks != "" {
+		r, err := parseChunkRange(*chunks)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitUsage
+		}
+		opts.Chunks = &r
+	}
+
+	world, err := readWorld(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFailure
+	}
+	filterChunks(world, opts)
+
+	list := make([]chunkSummary, 0, len(world.Chunks))
+	for _, c := range world.Chunks {
+		s := chunkSummary{
+			X:             c.X,
+			Z:             c.Z,
+			Sections:      len(c.Sections),
+			BlockEntities: len(c.TileEntities),
+			Entities:      len(c.Entities),
+			Size:          c.Size,
+		}
+		for i := range c.Sections {
+			if !sectionIsAir(&c.Sections[i]) {
+				s.BlockSections++
+			}
+		}
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Z != list[j].Z {
+			return list[i].Z < list[j].Z
+		}
+		return list[i].X < list[j].X
+	})
+
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(list); err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding chunks: %v\n", err)
+			return exitFailure
+		}
+		return exitOK
+	}
+
+	fmt.Printf("%6s %6s  %8s  %6s  %8s  %8s  %8s\n", "X", "Z", "sections", "blocks", "blk ents", "entities", "bytes")
+	for _, s := range list {
+		fmt.Printf("%6d %6d  %8d  %6d  %8d  %8d  %8d\n", s.X, s.Z, s.Sections, s.BlockSections, s.BlockEntities, s.Entities, s.Size)
+	}
+	fmt.Printf("%d chunks\n", len(list))
+	return exitOK
+}
+
+// sectionIsAir reports whether a section holds nothing but air.
+func sectionIsAir(s *slime.Section) bool {
+	for _, bs := range s.BlockPalette {
+		switch bs.Name {
+		case "minecraft:air", "minecraft:cave_air", "minecraft:void_air":
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/emmanuelvlad/slime2schem/anvil"
+	"github.com/emmanuelvlad/slime2schem/converter"
+	"github.com/emmanuelvlad/slime2schem/gltf"
+	"github.com/emmanuelvlad/slime2schem/slime"
+)
+
+// convertOptions holds the conversion flags shared by convert, batch and
+// watch.
+type convertOptions struct {
+	formatName   string
+	tileSpec     string
+	chunks       string
+	skipEntities bool
+	author       string
+	glb          gltf.Options
+
+	// Set by parse.
+	format outputFormat
+	tile   converter.TileOptions
+	read   anvil.ReadOptions
+}
+
+// register adds the conversion flags to fs.
+func (o *convertOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.formatName, "format", "v3", "Output format: v3 or v2 (Sponge .schem), litematic, structure (vanilla .nbt, split into 48x48x48 pieces if larger), mcedit (legacy 1.12 .schematic), mcstructure (Bedrock), glb (glTF 3D mesh), or vox (MagicaVoxel)")
+	fs.StringVar(&o.tileSpec, "tile", "", "Split the world into tiles of at most N or WxHxL blocks, written next to the output with a .manifest.json")
+	fs.StringVar(&o.chunks, "chunks", "", "Only convert chunks in the inclusive range x1,z1:x2,z2 (chunk coordinates)")
+	fs.BoolVar(&o.skipEntities, "skip-entities", false, "Do not export entities")
+	fs.StringVar(&o.author, "author", "", "Author stored in formats that record one (litematic); none by default")
+	fs.IntVar(&o.glb.MinY, "glb-min-y", 0, "glb format: leave out blocks below this Y, counted from the bottom of the schematic")
+}
+
+// parse validates the flags once they have been parsed.
+func (o *convertOptions) parse() error {
+	var err error
+	if o.format, err = parseOutputFormat(o.formatName); err != nil {
+		return err
+	}
+	if o.tileSpec != "" {
+		if o.tile, err = parseTileSpec(o.tileSpec); err != nil {
+			return err
+		}
+	}
+	o.read = anvil.ReadOptions{SkipEntities: o.skipEntities}
+	if o.chunks != "" {
+		r, err := parseChunkRange(o.chunks)
+		if err != nil {
+			return err
+		}
+		o.read.Chunks = &r
+	}
+	return nil
+}
+
+// tiled reports whether world is written as tiles next to the output path,
+// with a manifest, rather than to the output path itself: with -tile, or
+// when it is too large for one file of the format.
+func (o *convertOptions) tiled(world *slime.SlimeWorld) bool {
+	if o.tileSpec != "" {
+		return true
+	}
+	if o.format.maxSize == 0 {
+		return false
+	}
+	size := converter.Size(world)
+	return max(size[0], size[1], size[2]) > o.format.maxSize
+}
+
+// saveOptions returns the options for saving the file written to path.
+func (o *convertOptions) saveOptions(path string) saveOptions {
+	return saveOptions{name: schematicName(path), author: o.author, glb: o.glb}
+}
+
+// outputPath returns the default output path for input: the input name with
+// the format's extension.
+func (o *convertOptions) outputPath(input string) string {
+	input = filepath.Clean(input)
+	return strings.TrimSuffix(input, filepath.Ext(input)) + o.format.ext
+}
+
+// runConvert implements "slime2schem convert", which is also what runs when
+// no command is given.
+func runConvert(args []string) int {
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	inputFile := fs.String("input", "", "Path to the .slime or .polar file, or vanilla (Anvil) world directory, to convert")
+	outputFile := fs.String("output", "", "Path for the output file (default: input name with the format's extension)")
+	reportFile := fs.String("report", "", "Write a JSON report of dropped entities and block entities to this path (\"-\" for stdout)")
+	var opts convertOptions
+	opts.register(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: slime2schem convert [flags] <world.slime|world.polar|world dir> [output]\n")
+		fmt.Fprintf(os.Stderr, "\nConverts a world to a schematic (Sponge .schem by default), which can be\n")
+		fmt.Fprintf(os.Stderr, "pasted in Minecraft using WorldEdit.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	// Allow positional arguments as input and output
+	positional := fs.Args()
+	if *inputFile == "" && len(positional) > 0 {
+		*inputFile, positional = positional[0], positional[1:]
+	}
+	if *outputFile == "" && len(positional) > 0 {
+		*outputFile, positional = positional[0], positional[1:]
+	}
+	if *inputFile == "" || len(positional) > 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	if err := opts.parse(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitUsage
+	}
+	if *outputFile == "" {
+		*outputFile = opts.outputPath(*inputFile)
+	}
+
+	// Progress goes to stderr when the report is written to stdout, so
+	// the JSON can be piped.
+	var log io.Writer = os.Stdout
+	if *reportFile == "-" {
+		log = os.Stderr
+	}
+
+	fmt.Fprintf(log, "Reading world: %s\n", *inputFile)
+
+	world, err := readWorld(*inputFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFailure
+	}
+
+	fmt.Fprintf(log, "Parsed %d chunks (data version: %d)\n", len(world.Chunks), world.WorldVersion)
+
+	if err := convertWorld(world, *outputFile, *reportFile, &opts, log); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFailure
+	}
+	return exitOK
+}
+
+// convertWorld converts world with opts and writes the result to
+// outputFile, or to tiles next to it. Progress is printed to log.
+func convertWorld(world *slime.SlimeWorld, outputFile, reportFile string, opts *convertOptions, log io.Writer) error {
+	filterChunks(world, opts.read)
+	if len(world.Chunks) == 0 {
+		return fmt.Errorf("no chunks to convert")
+	}
+
+	if opts.tiled(world) {
+		if err := convertTiled(world, opts, outputFile, reportFile, log); err != nil {
+			return fmt.Errorf("converting: %w", err)
+		}
+		return nil
+	}
+
+	result, err := converter.Convert(world)
+	if err != nil {
+		return fmt.Errorf("converting: %w", err)
+	}
+
+	printBounds(log, result.Bounds)
+	fmt.Fprintf(log, "Converted %d non-air blocks (%d unique block states)\n",
+		result.TotalBlocks, len(result.Schematic.Palette))
+
+	if n := len(result.Report.Dropped); n > 0 {
+		fmt.Fprintf(log, "Dropped %d entities/block entities (use -report for details)\n", n)
+	}
+
+	schemData, unmapped, err := opts.format.save(result.Schematic, opts.saveOptions(outputFile))
+	if err != nil {
+		return fmt.Errorf("saving schematic: %w", err)
+	}
+	printUnmapped(log, unmapped, opts.formatName)
+
+	if reportFile != "" {
+		report := exportReport{Report: result.Report, Unmapped: unmapped}
+		if err := writeReport(reportFile, &report); err != nil {
+			return fmt.Errorf("writing report: %w", err)
+		}
+	}
+
+	if err := os.WriteFile(outputFile, schemData, 0644); err != nil {
+		return fmt.Errorf("writing output file: %w", err)
+	}
+
+	fmt.Fprintf(log, "Schematic saved to: %s\n", outputFile)
+	fmt.Fprintf(log, "Dimensions: %d x %d x %d (Width x Height x Length)\n",
+		result.Schematic.Width, result.Schematic.Height, result.Schematic.Length)
+	if opts.format.ext == ".schem" {
+		fmt.Fprintln(log, "\nYou can load this schematic in Minecraft using WorldEdit:")
+		fmt.Fprintln(log, "  //schematic load <filename>")
+		fmt.Fprintln(log, "  //paste")
+	}
+	return nil
+}
@@ -12,6 +12,14 @@ import (
 type ConvertResult struct {
 	Schematic   *schematic.Schematic
 	TotalBlocks int
+	// Bounds is the range of chunks and sections converted; it is zero for
+	// a merged conversion.
+	Bounds Bounds
+	// Origin is the world block position of the schematic's minimum corner.
+	Origin [3]int
+
+	// Report lists entities and block entities that were dropped.
+	Report Report
 }
 
 // Convert transforms a slime world into a Sponge Schematic v3 (.schem).
@@ -20,142 +28,288 @@ func Convert(world *slime.SlimeWorld) (*ConvertResult, error) {
 		return nil, fmt.Errorf("no chunks in world")
 	}
 
-	// Determine world bounds
-	minCX, minCZ := int32(math.MaxInt32), int32(math.MaxInt32)
-	maxCX, maxCZ := int32(math.MinInt32), int32(math.MinInt32)
-	minSY, maxSY := int32(math.MaxInt32), int32(math.MinInt32)
+	b := computeBounds(world)
+	width, height, length := b.size()
+
+	if width > 65535 || height > 65535 || length > 65535 {
+		return nil, fmt.Errorf("world too large for schematic: %dx%dx%d", width, height, length)
+	}
+
+	schem := schematic.NewSchematic(width, height, length, int32(world.WorldVersion))
+
+	// Set offset so that pasting centers the schematic on the player's X/Z position,
+	// with the bottom of the schematic at the player's Y position.
+	schem.Offset = [3]int32{-int32(width / 2), 0, -int32(length / 2)}
+
+	whole := region{size: [3]int{width, height, length}}
+	totalBlocks := fillBlocks(world, b, schem, whole, nil)
+
+	report := Report{Dropped: []DroppedObject{}}
+	placeObjects(world, b, schem, whole, &report)
+
+	return &ConvertResult{
+		Schematic:   schem,
+		TotalBlocks: totalBlocks,
+		Bounds:      b.export(),
+		Origin:      b.origin(),
+		Report:      report,
+	}, nil
+}
+
+// Bounds is the inclusive range of chunk coordinates and section Y
+// coordinates (block Y / 16) a world covers.
+type Bounds struct {
+	MinChunkX, MaxChunkX     int32
+	MinChunkZ, MaxChunkZ     int32
+	MinSectionY, MaxSectionY int32
+}
+
+// Size returns the width, height and length of the schematic Convert
+// makes from world.
+func Size(world *slime.SlimeWorld) [3]int {
+	width, height, length := computeBounds(world).size()
+	return [3]int{width, height, length}
+}
+
+// bounds is the chunk/section bounding box of a world. Section Y is in world
+// coordinates: a chunk's first section is at world.MinY / 16.
+type bounds struct {
+	minCX, maxCX int32
+	minCZ, maxCZ int32
+	minSY, maxSY int32
+}
+
+// computeBounds determines the chunk and section bounding box of a world.
+func computeBounds(world *slime.SlimeWorld) bounds {
+	b := bounds{
+		minCX: math.MaxInt32, maxCX: math.MinInt32,
+		minCZ: math.MaxInt32, maxCZ: math.MinInt32,
+		minSY: math.MaxInt32, maxSY: math.MinInt32,
+	}
+	minSection := int32(world.MinY >> 4)
 
 	for _, chunk := range world.Chunks {
-		if chunk.X < minCX {
-			minCX = chunk.X
+		if chunk.X < b.minCX {
+			b.minCX = chunk.X
 		}
-		if chunk.X > maxCX {
-			maxCX = chunk.X
+		if chunk.X > b.maxCX {
+			b.maxCX = chunk.X
 		}
-		if chunk.Z < minCZ {
-			minCZ = chunk.Z
+		if chunk.Z < b.minCZ {
+			b.minCZ = chunk.Z
 		}
-		if chunk.Z > maxCZ {
-			maxCZ = chunk.Z
+		if chunk.Z > b.maxCZ {
+			b.maxCZ = chunk.Z
 		}
 
 		for sIdx := range chunk.Sections {
-			sectionY := int32(sIdx)
-			if sectionY < minSY {
-				minSY = sectionY
+			sectionY := int32(sIdx) + minSection
+			if sectionY < b.minSY {
+				b.minSY = sectionY
 			}
-			if sectionY > maxSY {
-				maxSY = sectionY
+			if sectionY > b.maxSY {
+				b.maxSY = sectionY
 			}
 		}
 	}
 
-	if minSY > maxSY {
-		minSY = 0
-		maxSY = 0
+	if b.minSY > b.maxSY {
+		b.minSY = minSection
+		b.maxSY = minSection
 	}
+	return b
+}
 
-	// Calculate dimensions
-	chunksX := int(maxCX - minCX + 1)
-	chunksZ := int(maxCZ - minCZ + 1)
-	sectionsY := int(maxSY - minSY + 1)
-
-	width := chunksX * 16    // X axis
-	length := chunksZ * 16   // Z axis
-	height := sectionsY * 16 // Y axis
-
-	if width > 65535 || height > 65535 || length > 65535 {
-		return nil, fmt.Errorf("world too large for schematic: %dx%dx%d", width, height, length)
-	}
+func (b bounds) export() Bounds {
+	return Bounds{b.minCX, b.maxCX, b.minCZ, b.maxCZ, b.minSY, b.maxSY}
+}
 
-	fmt.Printf("World bounds: chunks X=[%d, %d] Z=[%d, %d] sections Y=[%d, %d]\n",
-		minCX, maxCX, minCZ, maxCZ, minSY, maxSY)
-	fmt.Printf("Schematic dimensions: %d x %d x %d (W x H x L)\n", width, height, length)
+// size returns the width (X), height (Y) and length (Z) of the bounding box in blocks.
+func (b bounds) size() (width, height, length int) {
+	width = int(b.maxCX-b.minCX+1) * 16
+	height = int(b.maxSY-b.minSY+1) * 16
+	length = int(b.maxCZ-b.minCZ+1) * 16
+	return width, height, length
+}
 
-	schem := schematic.NewSchematic(width, height, length, int32(world.WorldVersion))
+// origin returns the world block coordinates of the bounding box's minimum corner.
+func (b bounds) origin() [3]int {
+	return [3]int{int(b.minCX) * 16, int(b.minSY) * 16, int(b.minCZ) * 16}
+}
 
-	// Set offset so that pasting centers the schematic on the player's X/Z position,
-	// with the bottom of the schematic at the player's Y position.
-	schem.Offset = [3]int32{-int32(width / 2), 0, -int32(length / 2)}
+// region is a box inside the world bounding box, in coordinates relative
+// to the bounding box origin.
+type region struct {
+	min  [3]int
+	size [3]int
+}
 
+// fillBlocks copies every non-air block inside r into schem, which must be
+// r.size in dimensions. If onSet is non-nil it is called with the position of
+// each block before it is written. It returns the number of blocks written.
+func fillBlocks(world *slime.SlimeWorld, b bounds, schem *schematic.Schematic, r region, onSet func(x, y, z int)) int {
 	totalBlocks := 0
 
-	// Fill in blocks
 	for _, chunk := range world.Chunks {
-		// Chunk position relative to the schematic origin
-		baseX := int(chunk.X-minCX) * 16
-		baseZ := int(chunk.Z-minCZ) * 16
+		// Chunk position relative to the region origin
+		baseX := int(chunk.X-b.minCX)*16 - r.min[0]
+		baseZ := int(chunk.Z-b.minCZ)*16 - r.min[2]
+		if baseX+16 <= 0 || baseX >= r.size[0] || baseZ+16 <= 0 || baseZ >= r.size[2] {
+			continue
+		}
 
 		for sIdx, section := range chunk.Sections {
-			sectionY := int32(sIdx)
-			baseY := int(sectionY-minSY) * 16
+			sectionY := int32(sIdx) + int32(world.MinY>>4)
+			baseY := int(sectionY-b.minSY)*16 - r.min[1]
+			if baseY+16 <= 0 || baseY >= r.size[1] || len(section.BlockPalette) == 0 {
+				continue
+			}
+
+			// Build each palette entry's state string once per section;
+			// air entries stay empty and are skipped.
+			states := make([]string, len(section.BlockPalette))
+			for i, bs := range section.BlockPalette {
+				if bs.Name != "minecraft:air" && bs.Name != "minecraft:cave_air" && bs.Name != "minecraft:void_air" {
+					states[i] = schematic.BlockStateString(bs.Name, bs.Properties)
+				}
+			}
 
 			for y := 0; y < 16; y++ {
 				for z := 0; z < 16; z++ {
 					for x := 0; x < 16; x++ {
-						bs := section.GetBlockAt(x, y, z)
+						sx := baseX + x
+						sy := baseY + y
+						sz := baseZ + z
 
-						if bs.Name == "minecraft:air" || bs.Name == "minecraft:cave_air" || bs.Name == "minecraft:void_air" {
+						if sx < 0 || sx >= r.size[0] || sy < 0 || sy >= r.size[1] || sz < 0 || sz >= r.size[2] {
 							continue
 						}
 
-						sx := baseX + x
-						sy := baseY + y
-						sz := baseZ + z
+						blockState := states[section.PaletteIndexAt(x, y, z)]
+						if blockState == "" {
+							continue
+						}
 
-						if sx >= 0 && sx < width && sy >= 0 && sy < height && sz >= 0 && sz < length {
-							blockState := schematic.BlockStateString(bs.Name, bs.Properties)
-							schem.SetBlock(sx, sy, sz, blockState)
-							totalBlocks++
+						if onSet != nil {
+							onSet(sx, sy, sz)
 						}
+						schem.SetBlock(sx, sy, sz, blockState)
+						totalBlocks++
 					}
 				}
 			}
 		}
+	}
+
+	return totalBlocks
+}
 
-		// Add block entities with adjusted coordinates
+// placeObjects adds every block entity and entity located inside r to schem,
+// with coordinates relative to the region origin. Objects that cannot be
+// converted or lie outside the world bounding box are recorded in report,
+// if it is non-nil; objects inside the bounding box but outside r are
+// skipped silently, since they belong to another region.
+func placeObjects(world *slime.SlimeWorld, b bounds, schem *schematic.Schematic, r region, report *Report) {
+	width, height, length := b.size()
+	origin := b.origin()
+	offX, offY, offZ := origin[0]+r.min[0], origin[1]+r.min[1], origin[2]+r.min[2]
+
+	drop := func(kind string, chunk slime.Chunk, raw map[string]interface{}, reason DropReason) {
+		if report != nil {
+			report.drop(kind, [2]int32{chunk.X, chunk.Z}, raw, reason)
+		}
+	}
+
+	for _, chunk := range world.Chunks {
+		// Add block entities with adjusted coordinates (only if within bounds)
 		for _, te := range chunk.TileEntities {
-			be := adjustBlockEntity(te, int(minCX)*16, int(minSY)*16, int(minCZ)*16)
-			if be != nil {
+			be, reason := adjustBlockEntity(te, offX, offY, offZ)
+			if reason != "" {
+				drop(KindBlockEntity, chunk, te, reason)
+				continue
+			}
+			x, y, z := float64(be.Pos[0]), float64(be.Pos[1]), float64(be.Pos[2])
+			if inBounds(x, y, z, r.size[0], r.size[1], r.size[2]) {
 				schem.BlockEntities = append(schem.BlockEntities, *be)
+			} else if !inBounds(x+float64(r.min[0]), y+float64(r.min[1]), z+float64(r.min[2]), width, height, length) {
+				drop(KindBlockEntity, chunk, te, DropOutOfBounds)
 			}
 		}
 
-		// Add entities with adjusted coordinates (only if within schematic bounds)
+		// Add entities with adjusted coordinates (only if within bounds)
 		for _, ent := range chunk.Entities {
-			e := adjustEntity(ent, int(minCX)*16, int(minSY)*16, int(minCZ)*16)
-			if e != nil &&
-				e.Pos[0] >= 0 && e.Pos[0] < float64(width) &&
-				e.Pos[1] >= 0 && e.Pos[1] < float64(height) &&
-				e.Pos[2] >= 0 && e.Pos[2] < float64(length) {
+			e, reason := adjustEntity(ent, offX, offY, offZ)
+			if reason != "" {
+				drop(KindEntity, chunk, ent, reason)
+				continue
+			}
+			if inBounds(e.Pos[0], e.Pos[1], e.Pos[2], r.size[0], r.size[1], r.size[2]) {
 				schem.Entities = append(schem.Entities, *e)
+			} else if !inBounds(e.Pos[0]+float64(r.min[0]), e.Pos[1]+float64(r.min[1]), e.Pos[2]+float64(r.min[2]), width, height, length) {
+				drop(KindEntity, chunk, ent, DropOutOfBounds)
 			}
 		}
+
+		// Scheduled ticks are not reported; they only matter for the exporters
+		// that can store them.
+		schem.BlockTicks = appendTicks(schem.BlockTicks, chunk.BlockTicks, offX, offY, offZ, r)
+		schem.FluidTicks = appendTicks(schem.FluidTicks, chunk.FluidTicks, offX, offY, offZ, r)
 	}
+}
 
-	return &ConvertResult{
-		Schematic:   schem,
-		TotalBlocks: totalBlocks,
-	}, nil
+// appendTicks converts raw scheduled ticks ({i, x, y, z, t, p}) to schematic
+// ticks relative to the region origin, keeping only those inside r.
+func appendTicks(dst []schematic.Tick, ticks []map[string]interface{}, offsetX, offsetY, offsetZ int, r region) []schematic.Tick {
+	for _, raw := range ticks {
+		id, _ := raw["i"].(string)
+		x, xOk := getInt(raw, "x")
+		y, yOk := getInt(raw, "y")
+		z, zOk := getInt(raw, "z")
+		if id == "" || !xOk || !yOk || !zOk {
+			continue
+		}
+		x, y, z = x-offsetX, y-offsetY, z-offsetZ
+		if !inBounds(float64(x), float64(y), float64(z), r.size[0], r.size[1], r.size[2]) {
+			continue
+		}
+		delay, _ := getInt(raw, "t")
+		priority, _ := getInt(raw, "p")
+		dst = append(dst, schematic.Tick{
+			Pos:      [3]int32{int32(x), int32(y), int32(z)},
+			Id:       id,
+			Delay:    int32(delay),
+			Priority: int32(priority),
+		})
+	}
+	return dst
+}
+
+// inBounds reports whether a schematic-relative position lies inside the volume.
+func inBounds(x, y, z float64, width, height, length int) bool {
+	return x >= 0 && x < float64(width) &&
+		y >= 0 && y < float64(height) &&
+		z >= 0 && z < float64(length)
 }
 
 // adjustBlockEntity converts a raw tile entity map to a schematic BlockEntity
-// with coordinates relative to the schematic origin.
-func adjustBlockEntity(te map[string]interface{}, offsetX, offsetY, offsetZ int) *schematic.BlockEntity {
+// with coordinates relative to the schematic origin. Any other positional tags
+// known to RelocateBlockEntityData are shifted by the same offset.
+// If the tile entity cannot be converted, the reason is returned instead.
+func adjustBlockEntity(te map[string]interface{}, offsetX, offsetY, offsetZ int) (*schematic.BlockEntity, DropReason) {
 	id, _ := te["id"].(string)
 	if id == "" {
 		// Try capitalized variant
 		id, _ = te["Id"].(string)
 	}
 	if id == "" {
-		return nil
+		return nil, DropMissingID
 	}
 
 	x, xOk := getInt(te, "x")
 	y, yOk := getInt(te, "y")
 	z, zOk := getInt(te, "z")
 	if !xOk || !yOk || !zOk {
-		return nil
+		return nil, DropMissingPosition
 	}
 
 	// Build extra data (everything except id, x, y, z which are handled separately)
@@ -168,36 +322,39 @@ func adjustBlockEntity(te map[string]interface{}, offsetX, offsetY, offsetZ int)
 			data[k] = v
 		}
 	}
+	RelocateBlockEntityData(data, offsetX, offsetY, offsetZ)
 
 	return &schematic.BlockEntity{
 		Pos:  [3]int32{int32(x - offsetX), int32(y - offsetY), int32(z - offsetZ)},
 		Id:   id,
 		Data: data,
-	}
+	}, ""
 }
 
 // adjustEntity converts a raw entity map to a schematic Entity
-// with coordinates relative to the schematic origin.
-func adjustEntity(ent map[string]interface{}, offsetX, offsetY, offsetZ int) *schematic.Entity {
+// with coordinates relative to the schematic origin. Any other positional tags
+// known to RelocateEntityData (including passengers) are shifted by the same offset.
+// If the entity cannot be converted, the reason is returned instead.
+func adjustEntity(ent map[string]interface{}, offsetX, offsetY, offsetZ int) (*schematic.Entity, DropReason) {
 	id, _ := ent["id"].(string)
 	if id == "" {
 		id, _ = ent["Id"].(string)
 	}
 	if id == "" {
-		return nil
+		return nil, DropMissingID
 	}
 
 	// Entity position is stored in Pos as a double list [x, y, z]
 	pos, ok := ent["Pos"].([]interface{})
 	if !ok || len(pos) < 3 {
-		return nil
+		return nil, DropMissingPosition
 	}
 
 	px, pxOk := toFloat64(pos[0])
 	py, pyOk := toFloat64(pos[1])
 	pz, pzOk := toFloat64(pos[2])
 	if !pxOk || !pyOk || !pzOk {
-		return nil
+		return nil, DropMissingPosition
 	}
 
 	// Build extra data (everything except id and Pos)
@@ -210,12 +367,13 @@ func adjustEntity(ent map[string]interface{}, offsetX, offsetY, offsetZ int) *sc
 			data[k] = v
 		}
 	}
+	RelocateEntityData(data, offsetX, offsetY, offsetZ)
 
 	return &schematic.Entity{
 		Pos:  [3]float64{px - float64(offsetX), py - float64(offsetY), pz - float64(offsetZ)},
 		Id:   id,
 		Data: data,
-	}
+	}, ""
 }
 
 func getInt(m map[string]interface{}, key string) (int, bool) {
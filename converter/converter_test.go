@@ -0,0 +1,77 @@
+package converter
+
+import (
+	"testing"
+
+	"github.com/emmanuelvlad/slime2schem/internal/testworld"
+	"github.com/emmanuelvlad/slime2schem/slime"
+)
+
+func TestConvertUsesWorldMinY(t *testing.T) {
+	// A 1.18+ overworld chunk: section index 0 is at Y -64, so the floor
+	// in section index 1 is at Y -48.
+	world := testworld.Overworld()
+	world.Chunks[0].BlockTicks = []map[string]interface{}{
+		{"i": "minecraft:stone", "x": int32(3), "y": int32(-48), "z": int32(3), "t": int32(1), "p": int32(0)},
+	}
+
+	result, err := Convert(world)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := result.Bounds; got.MinSectionY != -4 || got.MaxSectionY != -3 {
+		t.Errorf("section Y range = [%d, %d], want [-4, -3]", got.MinSectionY, got.MaxSectionY)
+	}
+	if result.Origin != [3]int{0, -64, 0} {
+		t.Errorf("origin = %v, want [0 -64 0]", result.Origin)
+	}
+	if n := len(result.Report.Dropped); n != 0 {
+		t.Fatalf("dropped %d objects: %+v", n, result.Report.Dropped)
+	}
+
+	s := result.Schematic
+	if got := s.GetBlock(3, 16, 4); got != "minecraft:stone" {
+		t.Errorf("block under the chest = %s, want minecraft:stone", got)
+	}
+	if len(s.BlockEntities) != 1 || s.BlockEntities[0].Pos != [3]int32{3, 17, 4} {
+		t.Errorf("block entities = %+v, want a chest at 3,17,4", s.BlockEntities)
+	}
+	if len(s.Entities) != 1 || s.Entities[0].Pos != [3]float64{2.5, 17, 2.5} {
+		t.Errorf("entities = %+v, want a pig at 2.5,17,2.5", s.Entities)
+	}
+	if len(s.BlockTicks) != 1 || s.BlockTicks[0].Pos != [3]int32{3, 16, 3} {
+		t.Errorf("block ticks = %+v, want one at 3,16,3", s.BlockTicks)
+	}
+}
+
+func TestConvertDropReasons(t *testing.T) {
+	// DropOverlapped only happens when merging; see TestConvertMergedPriority.
+	tests := []struct {
+		kind string
+		raw  map[string]interface{}
+		want DropReason
+	}{
+		{KindBlockEntity, map[string]interface{}{"x": int32(1), "y": int32(1), "z": int32(1)}, DropMissingID},
+		{KindEntity, map[string]interface{}{"Pos": []interface{}{1.5, 1.0, 1.5}}, DropMissingID},
+		{KindBlockEntity, map[string]interface{}{"id": "minecraft:chest", "x": int32(1), "z": int32(1)}, DropMissingPosition},
+		{KindEntity, map[string]interface{}{"id": "minecraft:pig", "Pos": []interface{}{1.5, "up", 1.5}}, DropMissingPosition},
+		{KindBlockEntity, testworld.Chest(1, 40, 1), DropOutOfBounds},
+		{KindEntity, testworld.Pig(-0.5, 1, 1.5), DropOutOfBounds},
+	}
+	for _, tt := range tests {
+		chunk := slime.Chunk{Sections: []slime.Section{testworld.Floor("minecraft:stone")}}
+		if tt.kind == KindBlockEntity {
+			chunk.TileEntities = []map[string]interface{}{tt.raw}
+		} else {
+			chunk.Entities = []map[string]interface{}{tt.raw}
+		}
+		result, err := Convert(testworld.New(0, chunk))
+		if err != nil {
+			t.Fatal(err)
+		}
+		dropped := result.Report.Dropped
+		if len(dropped) != 1 || dropped[0].Kind != tt.kind || dropped[0].Reason != tt.want {
+			t.Errorf("%s %v: dropped %+v, want %q", tt.kind, tt.raw, dropped, tt.want)
+		}
+	}
+}
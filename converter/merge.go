@@ -0,0 +1,157 @@
+package converter
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/emmanuelvlad/slime2schem/schematic"
+	"github.com/emmanuelvlad/slime2schem/slime"
+)
+
+// Placement positions one slime world inside a merged schematic.
+type Placement struct {
+	World *slime.SlimeWorld
+	// Offset is added to the world's block coordinates before merging.
+	Offset [3]int
+	// Priority decides which world wins where blocks overlap: higher wins,
+	// and on equal priority the placement listed later wins. Air never
+	// overwrites anything.
+	Priority int
+}
+
+// ConvertMerged combines several slime worlds into a single Sponge Schematic
+// with a unified palette. Each world is shifted by its placement offset and
+// the schematic covers the union of all shifted worlds.
+//
+// Where a block from a higher-priority world replaces another block, the
+// replaced block's block entity is dropped and listed in the report.
+// Entities are never dropped for overlapping.
+//
+// The schematic's DataVersion is the highest WorldVersion among the inputs.
+func ConvertMerged(placements []Placement) (*ConvertResult, error) {
+	if len(placements) == 0 {
+		return nil, fmt.Errorf("no worlds to merge")
+	}
+
+	// Paint in ascending priority so higher priorities overwrite lower ones.
+	order := make([]int, len(placements))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return placements[order[a]].Priority < placements[order[b]].Priority
+	})
+
+	// Union of all shifted world volumes, in world block coordinates.
+	lo := [3]int{math.MaxInt, math.MaxInt, math.MaxInt}
+	hi := [3]int{math.MinInt, math.MinInt, math.MinInt}
+	worldBounds := make([]bounds, len(placements))
+	var dataVersion uint32
+	for i, p := range placements {
+		if p.World == nil || len(p.World.Chunks) == 0 {
+			return nil, fmt.Errorf("world %d: no chunks in world", i)
+		}
+		b := computeBounds(p.World)
+		worldBounds[i] = b
+		origin := b.origin()
+		w, h, l := b.size()
+		size := [3]int{w, h, l}
+		for axis := range lo {
+			lo[axis] = min(lo[axis], origin[axis]+p.Offset[axis])
+			hi[axis] = max(hi[axis], origin[axis]+p.Offset[axis]+size[axis])
+		}
+		dataVersion = max(dataVersion, p.World.WorldVersion)
+	}
+
+	width, height, length := hi[0]-lo[0], hi[1]-lo[1], hi[2]-lo[2]
+	if width > 65535 || height > 65535 || length > 65535 {
+		return nil, fmt.Errorf("merged world too large for schematic: %dx%dx%d", width, height, length)
+	}
+
+	schem := schematic.NewSchematic(width, height, length, int32(dataVersion))
+	schem.Offset = [3]int32{-int32(width / 2), 0, -int32(length / 2)}
+
+	report := Report{Dropped: []DroppedObject{}}
+	totalBlocks := 0
+
+	// Block entities placed so far, keyed by schematic position, so they can
+	// be dropped when a later (higher-priority) block replaces their block.
+	type placedBE struct {
+		index  int
+		source int
+	}
+	beAt := make(map[[3]int32]placedBE)
+	removed := make(map[int]bool)
+
+	for _, i := range order {
+		p := placements[i]
+		b := worldBounds[i]
+		origin := b.origin()
+		r := region{size: [3]int{width, height, length}}
+		for axis := range r.min {
+			r.min[axis] = lo[axis] - p.Offset[axis] - origin[axis]
+		}
+
+		replaced := 0
+		written := fillBlocks(p.World, b, schem, r, func(x, y, z int) {
+			if schem.GetBlock(x, y, z) != "minecraft:air" {
+				replaced++
+			}
+			key := [3]int32{int32(x), int32(y), int32(z)}
+			if prev, ok := beAt[key]; ok {
+				removed[prev.index] = true
+				delete(beAt, key)
+				be := schem.BlockEntities[prev.index]
+				report.Dropped = append(report.Dropped, overlappedBlockEntity(be, lo, placements[prev.source].Offset))
+			}
+		})
+
+		totalBlocks += written - replaced
+
+		first := len(schem.BlockEntities)
+		placeObjects(p.World, b, schem, r, &report)
+		for idx := first; idx < len(schem.BlockEntities); idx++ {
+			key := schem.BlockEntities[idx].Pos
+			if prev, ok := beAt[key]; ok {
+				removed[prev.index] = true
+				report.Dropped = append(report.Dropped, overlappedBlockEntity(schem.BlockEntities[prev.index], lo, placements[prev.source].Offset))
+			}
+			beAt[key] = placedBE{index: idx, source: i}
+		}
+	}
+
+	if len(removed) > 0 {
+		kept := schem.BlockEntities[:0]
+		for idx, be := range schem.BlockEntities {
+			if !removed[idx] {
+				kept = append(kept, be)
+			}
+		}
+		schem.BlockEntities = kept
+	}
+
+	return &ConvertResult{
+		Schematic:   schem,
+		TotalBlocks: totalBlocks,
+		Origin:      lo,
+		Report:      report,
+	}, nil
+}
+
+// overlappedBlockEntity builds the report entry for a block entity whose
+// block was replaced by a higher-priority world. The position is converted
+// back to the source world's own coordinates.
+func overlappedBlockEntity(be schematic.BlockEntity, lo, offset [3]int) DroppedObject {
+	var pos [3]float64
+	for axis := range pos {
+		pos[axis] = float64(int(be.Pos[axis]) + lo[axis] - offset[axis])
+	}
+	return DroppedObject{
+		Kind:   KindBlockEntity,
+		Id:     be.Id,
+		Chunk:  [2]int32{int32(math.Floor(pos[0] / 16)), int32(math.Floor(pos[2] / 16))},
+		Pos:    &pos,
+		Reason: DropOverlapped,
+	}
+}
@@ -0,0 +1,79 @@
+package converter
+
+import (
+	"testing"
+
+	"github.com/emmanuelvlad/slime2schem/internal/testworld"
+	"github.com/emmanuelvlad/slime2schem/slime"
+)
+
+func TestConvertMergedPriority(t *testing.T) {
+	world := func(blocks map[[3]int]string, tileEntities []map[string]interface{}) *slime.SlimeWorld {
+		return testworld.New(0, slime.Chunk{
+			Sections:     []slime.Section{testworld.Section("minecraft:air", blocks)},
+			TileEntities: tileEntities,
+		})
+	}
+	floor := make(map[[3]int]string)
+	for z := 0; z < 16; z++ {
+		for x := 0; x < 16; x++ {
+			floor[[3]int{x, 0, z}] = "minecraft:stone"
+		}
+	}
+	floor[[3]int{1, 1, 1}] = "minecraft:chest"
+	base := world(floor, []map[string]interface{}{testworld.Chest(1, 1, 1)})
+	diamond := world(map[[3]int]string{{2, 0, 2}: "minecraft:diamond_block"}, nil)
+	gold := world(map[[3]int]string{{1, 1, 1}: "minecraft:gold_block", {2, 0, 2}: "minecraft:gold_block"}, nil)
+
+	// The base is listed between the two others but has the lowest priority;
+	// gold is listed after diamond with the same priority, so it wins.
+	result, err := ConvertMerged([]Placement{
+		{World: diamond, Priority: 1},
+		{World: base},
+		{World: gold, Priority: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := result.Schematic
+	for pos, want := range map[[3]int]string{
+		{0, 0, 0}: "minecraft:stone", // air in the other worlds does not overwrite
+		{2, 0, 2}: "minecraft:gold_block",
+		{1, 1, 1}: "minecraft:gold_block",
+	} {
+		if got := s.GetBlock(pos[0], pos[1], pos[2]); got != want {
+			t.Errorf("block at %v = %s, want %s", pos, got, want)
+		}
+	}
+	if result.TotalBlocks != 257 {
+		t.Errorf("total blocks = %d, want 257", result.TotalBlocks)
+	}
+	if len(s.BlockEntities) != 0 {
+		t.Errorf("block entities = %+v, want the chest dropped", s.BlockEntities)
+	}
+	dropped := result.Report.Dropped
+	if len(dropped) != 1 || dropped[0].Reason != DropOverlapped || dropped[0].Pos == nil || *dropped[0].Pos != [3]float64{1, 1, 1} {
+		t.Errorf("dropped %+v, want the chest at 1,1,1 overlapped", dropped)
+	}
+}
+
+func TestConvertMergedOffsets(t *testing.T) {
+	a := testworld.New(0, slime.Chunk{Sections: []slime.Section{testworld.Section("minecraft:air", map[[3]int]string{{0, 0, 0}: "minecraft:stone"})}})
+	b := testworld.New(0, slime.Chunk{Sections: []slime.Section{testworld.Section("minecraft:air", map[[3]int]string{{0, 0, 0}: "minecraft:dirt"})}})
+
+	result, err := ConvertMerged([]Placement{{World: a}, {World: b, Offset: [3]int{32, 16, -16}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := result.Schematic
+	if s.Width != 48 || s.Height != 32 || s.Length != 32 || result.Origin != [3]int{0, 0, -16} {
+		t.Fatalf("size %dx%dx%d at %v, want 48x32x32 at [0 0 -16]", s.Width, s.Height, s.Length, result.Origin)
+	}
+	if got := s.GetBlock(0, 0, 16); got != "minecraft:stone" {
+		t.Errorf("first world's block = %s, want minecraft:stone", got)
+	}
+	if got := s.GetBlock(32, 16, 0); got != "minecraft:dirt" {
+		t.Errorf("second world's block = %s, want minecraft:dirt", got)
+	}
+}
@@ -0,0 +1,246 @@
+package converter
+
+// Positional NBT catalogue.
+//
+// Entities and block entities carry many coordinates besides their own
+// position: hanging entities remember the block they are attached to, bees
+// remember their hive and flower, villagers remember their bed and job site,
+// end gateways remember their exit portal, and so on. All of these are stored
+// in world coordinates and must be shifted by the same offset as Pos/x/y/z,
+// otherwise they point at the wrong place once the schematic is pasted.
+
+// posEncoding describes how a single position is stored in NBT.
+type posEncoding int
+
+const (
+	posIntArray    posEncoding = iota // [I; x, y, z]
+	posCompoundXYZ                    // {X: x, Y: y, Z: z}
+	posDoubleList                     // [x, y, z] as a list of doubles
+)
+
+// posTag is a named tag holding a complete position.
+type posTag struct {
+	Name     string
+	Encoding posEncoding
+}
+
+// entityPosTags lists every known single-tag position on entities.
+// Both the pre-1.20.5 (CamelCase) and current (snake_case) names are listed.
+var entityPosTags = []posTag{
+	{"block_pos", posIntArray},       // item frames, paintings, leash knots (1.21+)
+	{"leash", posIntArray},           // leashed to a fence knot (1.21+)
+	{"Leash", posCompoundXYZ},        // leashed to a fence knot (pre-1.21)
+	{"sleeping_pos", posIntArray},    // sleeping villagers/players
+	{"hive_pos", posIntArray},        // bees
+	{"HivePos", posCompoundXYZ},      // bees (pre-1.20.5)
+	{"flower_pos", posIntArray},      // bees
+	{"FlowerPos", posCompoundXYZ},    // bees (pre-1.20.5)
+	{"beam_target", posIntArray},     // end crystals
+	{"BeamTarget", posCompoundXYZ},   // end crystals (pre-1.20.5)
+	{"home_pos", posIntArray},        // turtles
+	{"travel_pos", posIntArray},      // turtles
+	{"bound_pos", posIntArray},       // vexes
+	{"anchor_pos", posIntArray},      // phantoms
+	{"wander_target", posIntArray},   // wandering traders
+	{"WanderTarget", posCompoundXYZ}, // wandering traders (pre-1.20.5)
+	{"patrol_target", posIntArray},   // raid patrol leaders
+	{"PatrolTarget", posCompoundXYZ}, // raid patrol leaders (pre-1.20.5)
+}
+
+// entitySplitPosTags lists positions stored as three separate int tags.
+var entitySplitPosTags = [][3]string{
+	{"TileX", "TileY", "TileZ"},             // item frames, paintings (pre-1.21)
+	{"SleepingX", "SleepingY", "SleepingZ"}, // sleeping villagers/players (pre-1.20.5)
+	{"HomePosX", "HomePosY", "HomePosZ"},    // turtles (pre-1.20.5)
+	{"TravelPosX", "TravelPosY", "TravelPosZ"},
+	{"BoundX", "BoundY", "BoundZ"}, // vexes (pre-1.20.5)
+	{"AX", "AY", "AZ"},             // phantoms (pre-1.20.5)
+}
+
+// brainMemoryPosKeys lists brain memories whose value is a global position
+// ({pos: [I; x, y, z], dimension: "..."}).
+var brainMemoryPosKeys = []string{
+	"minecraft:home",
+	"minecraft:job_site",
+	"minecraft:potential_job_site",
+	"minecraft:meeting_point",
+}
+
+// blockEntityPosTags lists every known single-tag position on block entities,
+// in addition to x/y/z which are handled by adjustBlockEntity.
+var blockEntityPosTags = []posTag{
+	{"exit_portal", posIntArray},   // end gateways
+	{"ExitPortal", posCompoundXYZ}, // end gateways (pre-1.20.5)
+	{"flower_pos", posIntArray},    // beehives
+	{"FlowerPos", posCompoundXYZ},  // beehives (pre-1.20.5)
+}
+
+// RelocateEntityData shifts every positional tag in an entity's NBT by
+// (-dx, -dy, -dz). It recurses into passengers, which carry their own Pos.
+// The input map is modified in place; nested compounds are cloned first so
+// the source world is never mutated.
+func RelocateEntityData(data map[string]interface{}, dx, dy, dz int) {
+	for _, tag := range entityPosTags {
+		shiftPosTag(data, tag, dx, dy, dz)
+	}
+	for _, names := range entitySplitPosTags {
+		shiftSplitPos(data, names, dx, dy, dz)
+	}
+
+	if brain, ok := data["Brain"].(map[string]interface{}); ok {
+		brain = cloneCompound(brain)
+		if memories, ok := brain["memories"].(map[string]interface{}); ok {
+			memories = cloneCompound(memories)
+			for _, key := range brainMemoryPosKeys {
+				mem, ok := memories[key].(map[string]interface{})
+				if !ok {
+					continue
+				}
+				mem = cloneCompound(mem)
+				if value, ok := mem["value"].(map[string]interface{}); ok {
+					value = cloneCompound(value)
+					shiftPosTag(value, posTag{"pos", posIntArray}, dx, dy, dz)
+					mem["value"] = value
+				}
+				memories[key] = mem
+			}
+			brain["memories"] = memories
+		}
+		data["Brain"] = brain
+	}
+
+	if passengers, ok := data["Passengers"].([]interface{}); ok {
+		out := make([]interface{}, len(passengers))
+		for i, p := range passengers {
+			pm, ok := p.(map[string]interface{})
+			if !ok {
+				out[i] = p
+				continue
+			}
+			pm = cloneCompound(pm)
+			shiftPosTag(pm, posTag{"Pos", posDoubleList}, dx, dy, dz)
+			RelocateEntityData(pm, dx, dy, dz)
+			out[i] = pm
+		}
+		data["Passengers"] = out
+	}
+}
+
+// RelocateBlockEntityData shifts every positional tag in a block entity's
+// NBT (other than x/y/z) by (-dx, -dy, -dz), including the stored entity
+// data of bees inside beehives.
+func RelocateBlockEntityData(data map[string]interface{}, dx, dy, dz int) {
+	for _, tag := range blockEntityPosTags {
+		shiftPosTag(data, tag, dx, dy, dz)
+	}
+
+	// Bees stored in a beehive/bee nest keep their full entity NBT.
+	for _, list := range [][2]string{{"Bees", "EntityData"}, {"bees", "entity_data"}} {
+		bees, ok := data[list[0]].([]interface{})
+		if !ok {
+			continue
+		}
+		out := make([]interface{}, len(bees))
+		for i, b := range bees {
+			bm, ok := b.(map[string]interface{})
+			if !ok {
+				out[i] = b
+				continue
+			}
+			bm = cloneCompound(bm)
+			if ed, ok := bm[list[1]].(map[string]interface{}); ok {
+				ed = cloneCompound(ed)
+				RelocateEntityData(ed, dx, dy, dz)
+				bm[list[1]] = ed
+			}
+			out[i] = bm
+		}
+		data[list[0]] = out
+	}
+}
+
+// shiftPosTag shifts a single position tag if present and well-formed.
+func shiftPosTag(m map[string]interface{}, tag posTag, dx, dy, dz int) {
+	v, ok := m[tag.Name]
+	if !ok {
+		return
+	}
+	d := [3]int{dx, dy, dz}
+
+	switch tag.Encoding {
+	case posIntArray:
+		arr, ok := v.([]int32)
+		if !ok || len(arr) != 3 {
+			return
+		}
+		out := make([]int32, 3)
+		for i := range out {
+			out[i] = arr[i] - int32(d[i])
+		}
+		m[tag.Name] = out
+
+	case posCompoundXYZ:
+		c, ok := v.(map[string]interface{})
+		if !ok {
+			return
+		}
+		c = cloneCompound(c)
+		shiftSplitPos(c, [3]string{"X", "Y", "Z"}, dx, dy, dz)
+		m[tag.Name] = c
+
+	case posDoubleList:
+		list, ok := v.([]interface{})
+		if !ok || len(list) != 3 {
+			return
+		}
+		out := make([]interface{}, 3)
+		for i := range out {
+			out[i] = shiftNumber(list[i], d[i])
+		}
+		m[tag.Name] = out
+	}
+}
+
+// shiftSplitPos shifts a position stored as three separate numeric tags.
+// Nothing is changed unless all three tags are present.
+func shiftSplitPos(m map[string]interface{}, names [3]string, dx, dy, dz int) {
+	for _, n := range names {
+		if _, ok := m[n]; !ok {
+			return
+		}
+	}
+	d := [3]int{dx, dy, dz}
+	for i, n := range names {
+		m[n] = shiftNumber(m[n], d[i])
+	}
+}
+
+// shiftNumber subtracts d from a numeric NBT value, preserving its type.
+// Non-numeric values are returned unchanged.
+func shiftNumber(v interface{}, d int) interface{} {
+	switch val := v.(type) {
+	case int16:
+		return val - int16(d)
+	case int32:
+		return val - int32(d)
+	case int64:
+		return val - int64(d)
+	case int:
+		return val - d
+	case float32:
+		return val - float32(d)
+	case float64:
+		return val - float64(d)
+	default:
+		return v
+	}
+}
+
+// cloneCompound returns a shallow copy of an NBT compound.
+func cloneCompound(m map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
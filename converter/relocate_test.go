@@ -0,0 +1,141 @@
+package converter
+
+import (
+	"reflect"
+	"testing"
+)
+
+// xyz returns a posCompoundXYZ position.
+func xyz(x, y, z int32) map[string]interface{} {
+	return map[string]interface{}{"X": x, "Y": y, "Z": z}
+}
+
+// memory returns a brain memory holding a global position.
+func memory(x, y, z int32) map[string]interface{} {
+	return map[string]interface{}{"value": map[string]interface{}{
+		"pos":       []int32{x, y, z},
+		"dimension": "minecraft:overworld",
+	}}
+}
+
+func TestRelocateEntityData(t *testing.T) {
+	tests := []struct {
+		name     string
+		in, want map[string]interface{}
+	}{
+		{
+			"int array",
+			map[string]interface{}{"hive_pos": []int32{10, 20, 30}, "block_pos": []int32{1, 2, 3}},
+			map[string]interface{}{"hive_pos": []int32{9, 18, 27}, "block_pos": []int32{0, 0, 0}},
+		},
+		{
+			"XYZ compound",
+			map[string]interface{}{"Leash": xyz(10, 20, 30), "FlowerPos": xyz(-5, 64, 7)},
+			map[string]interface{}{"Leash": xyz(9, 18, 27), "FlowerPos": xyz(-6, 62, 4)},
+		},
+		{
+			"split tags",
+			map[string]interface{}{"TileX": int32(10), "TileY": int32(20), "TileZ": int32(30), "AX": int32(0), "AY": int32(0), "AZ": int32(0)},
+			map[string]interface{}{"TileX": int32(9), "TileY": int32(18), "TileZ": int32(27), "AX": int32(-1), "AY": int32(-2), "AZ": int32(-3)},
+		},
+		{
+			"incomplete split tags",
+			map[string]interface{}{"TileX": int32(10), "TileY": int32(20)},
+			map[string]interface{}{"TileX": int32(10), "TileY": int32(20)},
+		},
+		{
+			"brain memories",
+			map[string]interface{}{"Brain": map[string]interface{}{"memories": map[string]interface{}{
+				"minecraft:home":     memory(10, 20, 30),
+				"minecraft:job_site": memory(1, 2, 3),
+				"minecraft:angry_at": map[string]interface{}{"value": []int32{7, 7, 7, 7}},
+			}}},
+			map[string]interface{}{"Brain": map[string]interface{}{"memories": map[string]interface{}{
+				"minecraft:home":     memory(9, 18, 27),
+				"minecraft:job_site": memory(0, 0, 0),
+				"minecraft:angry_at": map[string]interface{}{"value": []int32{7, 7, 7, 7}},
+			}}},
+		},
+		{
+			"passengers",
+			map[string]interface{}{"Passengers": []interface{}{
+				map[string]interface{}{"Pos": []interface{}{10.5, 20.0, 30.5}, "Passengers": []interface{}{
+					map[string]interface{}{"Pos": []interface{}{10.5, 21.0, 30.5}, "Leash": xyz(10, 20, 30)},
+				}},
+			}},
+			map[string]interface{}{"Passengers": []interface{}{
+				map[string]interface{}{"Pos": []interface{}{9.5, 18.0, 27.5}, "Passengers": []interface{}{
+					map[string]interface{}{"Pos": []interface{}{9.5, 19.0, 27.5}, "Leash": xyz(9, 18, 27)},
+				}},
+			}},
+		},
+		{
+			"malformed",
+			map[string]interface{}{"hive_pos": []int32{1, 2}, "Leash": "fence", "Passengers": []interface{}{"pig"}},
+			map[string]interface{}{"hive_pos": []int32{1, 2}, "Leash": "fence", "Passengers": []interface{}{"pig"}},
+		},
+	}
+	for _, tt := range tests {
+		RelocateEntityData(tt.in, 1, 2, 3)
+		if !reflect.DeepEqual(tt.in, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, tt.in, tt.want)
+		}
+	}
+}
+
+func TestRelocateBlockEntityData(t *testing.T) {
+	tests := []struct {
+		name     string
+		in, want map[string]interface{}
+	}{
+		{
+			"end gateway",
+			map[string]interface{}{"exit_portal": []int32{100, 70, -100}, "ExitPortal": xyz(100, 70, -100)},
+			map[string]interface{}{"exit_portal": []int32{99, 68, -103}, "ExitPortal": xyz(99, 68, -103)},
+		},
+		{
+			"beehive",
+			map[string]interface{}{
+				"flower_pos": []int32{5, 5, 5},
+				"bees":       []interface{}{map[string]interface{}{"entity_data": map[string]interface{}{"hive_pos": []int32{4, 4, 4}}}},
+			},
+			map[string]interface{}{
+				"flower_pos": []int32{4, 3, 2},
+				"bees":       []interface{}{map[string]interface{}{"entity_data": map[string]interface{}{"hive_pos": []int32{3, 2, 1}}}},
+			},
+		},
+		{
+			"beehive before 1.20.5",
+			map[string]interface{}{
+				"FlowerPos": xyz(5, 5, 5),
+				"Bees":      []interface{}{map[string]interface{}{"EntityData": map[string]interface{}{"HivePos": xyz(4, 4, 4)}, "TicksInHive": int32(9)}},
+			},
+			map[string]interface{}{
+				"FlowerPos": xyz(4, 3, 2),
+				"Bees":      []interface{}{map[string]interface{}{"EntityData": map[string]interface{}{"HivePos": xyz(3, 2, 1)}, "TicksInHive": int32(9)}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		RelocateBlockEntityData(tt.in, 1, 2, 3)
+		if !reflect.DeepEqual(tt.in, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, tt.in, tt.want)
+		}
+	}
+}
+
+func TestRelocateLeavesSourceUnchanged(t *testing.T) {
+	leash := xyz(10, 20, 30)
+	bee := map[string]interface{}{"EntityData": map[string]interface{}{"HivePos": xyz(4, 4, 4)}}
+	entity := map[string]interface{}{"Leash": leash}
+	hive := map[string]interface{}{"Bees": []interface{}{bee}}
+
+	RelocateEntityData(entity, 1, 2, 3)
+	RelocateBlockEntityData(hive, 1, 2, 3)
+	if !reflect.DeepEqual(leash, xyz(10, 20, 30)) {
+		t.Errorf("source leash changed to %v", leash)
+	}
+	if got := bee["EntityData"].(map[string]interface{})["HivePos"]; !reflect.DeepEqual(got, xyz(4, 4, 4)) {
+		t.Errorf("source bee changed to %v", got)
+	}
+}
@@ -0,0 +1,74 @@
+package converter
+
+// DropReason explains why an entity or block entity was left out of the schematic.
+type DropReason string
+
+const (
+	DropMissingID       DropReason = "missing id"
+	DropMissingPosition DropReason = "missing or invalid position"
+	DropOutOfBounds     DropReason = "outside schematic bounds"
+	DropOverlapped      DropReason = "overlapped by higher-priority world"
+)
+
+// Object kinds used in a Report.
+const (
+	KindEntity      = "entity"
+	KindBlockEntity = "block_entity"
+)
+
+// DroppedObject describes a single entity or block entity that did not make
+// it into the schematic.
+type DroppedObject struct {
+	Kind   string      `json:"kind"`
+	Id     string      `json:"id,omitempty"`
+	Chunk  [2]int32    `json:"chunk"`
+	Pos    *[3]float64 `json:"pos,omitempty"` // original world position, if it could be read
+	Reason DropReason  `json:"reason"`
+}
+
+// Report lists everything the conversion had to leave out.
+type Report struct {
+	Dropped []DroppedObject `json:"dropped"`
+}
+
+func (r *Report) drop(kind string, chunk [2]int32, raw map[string]interface{}, reason DropReason) {
+	id, _ := raw["id"].(string)
+	if id == "" {
+		id, _ = raw["Id"].(string)
+	}
+	r.Dropped = append(r.Dropped, DroppedObject{
+		Kind:   kind,
+		Id:     id,
+		Chunk:  chunk,
+		Pos:    rawPosition(kind, raw),
+		Reason: reason,
+	})
+}
+
+// rawPosition reads the world position of an unconverted entity (Pos) or
+// block entity (x/y/z). It returns nil if the position is missing or malformed.
+func rawPosition(kind string, raw map[string]interface{}) *[3]float64 {
+	if kind == KindBlockEntity {
+		x, xOk := getInt(raw, "x")
+		y, yOk := getInt(raw, "y")
+		z, zOk := getInt(raw, "z")
+		if !xOk || !yOk || !zOk {
+			return nil
+		}
+		return &[3]float64{float64(x), float64(y), float64(z)}
+	}
+
+	pos, ok := raw["Pos"].([]interface{})
+	if !ok || len(pos) < 3 {
+		return nil
+	}
+	var out [3]float64
+	for i := range out {
+		v, ok := toFloat64(pos[i])
+		if !ok {
+			return nil
+		}
+		out[i] = v
+	}
+	return &out
+}
@@ -0,0 +1,169 @@
+package converter
+
+import (
+	"fmt"
+
+	"github.com/emmanuelvlad/slime2schem/schematic"
+	"github.com/emmanuelvlad/slime2schem/slime"
+)
+
+// MaxTileSize is the largest dimension a single schematic can have.
+const MaxTileSize = 65535
+
+// TileOptions controls how a world is split into tiles.
+// A zero or out-of-range maximum is treated as MaxTileSize.
+type TileOptions struct {
+	MaxWidth  int // X axis
+	MaxHeight int // Y axis
+	MaxLength int // Z axis
+}
+
+// Tile is one schematic of a tiled conversion.
+type Tile struct {
+	// Index is the tile's position in the tile grid (X, Y, Z).
+	Index [3]int
+	// Origin is the world block position of the tile's minimum corner.
+	Origin [3]int
+	// Offset is the tile's minimum corner relative to the volume's minimum corner.
+	Offset      [3]int
+	Schematic   *schematic.Schematic
+	TotalBlocks int
+}
+
+// TiledResult summarises a tiled conversion.
+type TiledResult struct {
+	// Grid is the number of tiles along X, Y and Z.
+	Grid [3]int
+	// Bounds is the range of chunks and sections converted.
+	Bounds Bounds
+	// Origin is the world block position of the whole volume's minimum corner.
+	Origin [3]int
+	// Size is the width, height and length of the whole volume.
+	Size [3]int
+	// TileSize is the nominal tile size; tiles on the far edges may be smaller.
+	TileSize    [3]int
+	Tiles       int
+	TotalBlocks int
+
+	// Report lists entities and block entities that were dropped.
+	Report Report
+}
+
+// ConvertTiled splits a slime world into a grid of schematics no larger than
+// the sizes in opts and calls fn with each one in turn. Tiles are produced one
+// at a time so only a single tile's block data is held in memory.
+//
+// Each tile's Offset is set so that pasting every tile at the same position
+// reassembles the world centered on that position, just like Convert.
+// Tiles containing no blocks, block entities or entities are skipped.
+func ConvertTiled(world *slime.SlimeWorld, opts TileOptions, fn func(*Tile) error) (*TiledResult, error) {
+	if len(world.Chunks) == 0 {
+		return nil, fmt.Errorf("no chunks in world")
+	}
+
+	b := computeBounds(world)
+	width, height, length := b.size()
+	size := [3]int{width, height, length}
+	tileSize := [3]int{clampTile(opts.MaxWidth), clampTile(opts.MaxHeight), clampTile(opts.MaxLength)}
+
+	result := &TiledResult{
+		Bounds:   b.export(),
+		Origin:   b.origin(),
+		Size:     size,
+		TileSize: tileSize,
+		Report:   Report{Dropped: []DroppedObject{}},
+	}
+	for i := range result.Grid {
+		result.Grid[i] = (size[i] + tileSize[i] - 1) / tileSize[i]
+	}
+
+	first := true
+	for ty := 0; ty < result.Grid[1]; ty++ {
+		for tz := 0; tz < result.Grid[2]; tz++ {
+			for tx := 0; tx < result.Grid[0]; tx++ {
+				r := region{min: [3]int{tx * tileSize[0], ty * tileSize[1], tz * tileSize[2]}}
+				for i := range r.size {
+					r.size[i] = min(tileSize[i], size[i]-r.min[i])
+				}
+
+				schem := schematic.NewSchematic(r.size[0], r.size[1], r.size[2], int32(world.WorldVersion))
+				schem.Offset = [3]int32{
+					int32(r.min[0] - width/2),
+					int32(r.min[1]),
+					int32(r.min[2] - length/2),
+				}
+
+				totalBlocks := fillBlocks(world, b, schem, r, nil)
+
+				// Drops do not depend on the region, so only record them once.
+				var report *Report
+				if first {
+					report = &result.Report
+					first = false
+				}
+				placeObjects(world, b, schem, r, report)
+
+				if totalBlocks == 0 && len(schem.BlockEntities) == 0 && len(schem.Entities) == 0 {
+					continue
+				}
+
+				tile := &Tile{
+					Index:       [3]int{tx, ty, tz},
+					Origin:      [3]int{result.Origin[0] + r.min[0], result.Origin[1] + r.min[1], result.Origin[2] + r.min[2]},
+					Offset:      r.min,
+					Schematic:   schem,
+					TotalBlocks: totalBlocks,
+				}
+				if err := fn(tile); err != nil {
+					return nil, fmt.Errorf("tile %d,%d,%d: %w", tx, ty, tz, err)
+				}
+				result.Tiles++
+				result.TotalBlocks += totalBlocks
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func clampTile(n int) int {
+	if n <= 0 || n > MaxTileSize {
+		return MaxTileSize
+	}
+	return n
+}
+
+// Manifest describes a set of tile schematics written to disk.
+type Manifest struct {
+	DataVersion int32 `json:"dataVersion"`
+	// Origin is the world block position of the whole volume's minimum corner.
+	Origin   [3]int         `json:"origin"`
+	Size     [3]int         `json:"size"`
+	TileSize [3]int         `json:"tileSize"`
+	Grid     [3]int         `json:"grid"`
+	Tiles    []ManifestTile `json:"tiles"`
+}
+
+// ManifestTile describes one tile schematic in a Manifest.
+type ManifestTile struct {
+	File  string `json:"file"`
+	Index [3]int `json:"index"`
+	// Origin is the world block position of the tile's minimum corner.
+	Origin [3]int `json:"origin"`
+	// Offset is the tile's minimum corner relative to the volume's minimum corner.
+	Offset      [3]int `json:"offset"`
+	Size        [3]int `json:"size"`
+	TotalBlocks int    `json:"totalBlocks"`
+}
+
+// NewManifestTile builds the manifest entry for a tile written to file.
+func NewManifestTile(file string, tile *Tile) ManifestTile {
+	return ManifestTile{
+		File:        file,
+		Index:       tile.Index,
+		Origin:      tile.Origin,
+		Offset:      tile.Offset,
+		Size:        [3]int{tile.Schematic.Width, tile.Schematic.Height, tile.Schematic.Length},
+		TotalBlocks: tile.TotalBlocks,
+	}
+}
@@ -0,0 +1,83 @@
+package converter
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/emmanuelvlad/slime2schem/internal/testworld"
+	"github.com/emmanuelvlad/slime2schem/slime"
+)
+
+func TestConvertTiled(t *testing.T) {
+	// Two chunks along X with a stone floor, cut into tiles 10 blocks wide:
+	// 10, 10, 10 and 2 blocks. Objects sit on both sides of tile edges.
+	floor := []slime.Section{testworld.Floor("minecraft:stone")}
+	world := testworld.New(0,
+		slime.Chunk{X: 0, Z: 0, Sections: floor,
+			TileEntities: []map[string]interface{}{testworld.Chest(10, 1, 0)},
+			Entities:     []map[string]interface{}{testworld.Pig(9.5, 1, 3.5)},
+		},
+		slime.Chunk{X: 1, Z: 0, Sections: floor,
+			TileEntities: []map[string]interface{}{testworld.Chest(20, 1, 5)},
+			Entities:     []map[string]interface{}{testworld.Pig(31.5, 1, 15.5)},
+		},
+	)
+
+	var tiles []ManifestTile
+	blockEntities := make(map[string][3]int32)
+	entities := make(map[string][3]float64)
+	result, err := ConvertTiled(world, TileOptions{MaxWidth: 10}, func(tile *Tile) error {
+		name := fmt.Sprintf("tile_%d", tile.Index[0])
+		tiles = append(tiles, NewManifestTile(name, tile))
+		if want := [3]int32{int32(tile.Offset[0]) - 16, 0, -8}; tile.Schematic.Offset != want {
+			t.Errorf("%s: paste offset %v, want %v", name, tile.Schematic.Offset, want)
+		}
+		for _, be := range tile.Schematic.BlockEntities {
+			key := fmt.Sprintf("%s %s", name, be.Id)
+			if _, dup := blockEntities[key]; dup {
+				t.Errorf("%s has two block entities", name)
+			}
+			blockEntities[key] = be.Pos
+		}
+		for _, e := range tile.Schematic.Entities {
+			entities[fmt.Sprintf("%s %s", name, e.Id)] = e.Pos
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Grid != [3]int{4, 1, 1} || result.Tiles != 4 || result.TotalBlocks != 512 {
+		t.Errorf("grid %v, %d tiles, %d blocks; want [4 1 1], 4, 512", result.Grid, result.Tiles, result.TotalBlocks)
+	}
+	if len(result.Report.Dropped) != 0 {
+		t.Errorf("dropped %+v", result.Report.Dropped)
+	}
+	wantTiles := []ManifestTile{
+		{File: "tile_0", Index: [3]int{0, 0, 0}, Origin: [3]int{0, 0, 0}, Offset: [3]int{0, 0, 0}, Size: [3]int{10, 16, 16}, TotalBlocks: 160},
+		{File: "tile_1", Index: [3]int{1, 0, 0}, Origin: [3]int{10, 0, 0}, Offset: [3]int{10, 0, 0}, Size: [3]int{10, 16, 16}, TotalBlocks: 160},
+		{File: "tile_2", Index: [3]int{2, 0, 0}, Origin: [3]int{20, 0, 0}, Offset: [3]int{20, 0, 0}, Size: [3]int{10, 16, 16}, TotalBlocks: 160},
+		{File: "tile_3", Index: [3]int{3, 0, 0}, Origin: [3]int{30, 0, 0}, Offset: [3]int{30, 0, 0}, Size: [3]int{2, 16, 16}, TotalBlocks: 32},
+	}
+	if !reflect.DeepEqual(tiles, wantTiles) {
+		t.Errorf("manifest tiles = %+v, want %+v", tiles, wantTiles)
+	}
+
+	// Every object lands in exactly one tile, at its tile-relative position.
+	wantBlockEntities := map[string][3]int32{
+		"tile_1 minecraft:chest": {0, 1, 0},
+		"tile_2 minecraft:chest": {0, 1, 5},
+	}
+	if !reflect.DeepEqual(blockEntities, wantBlockEntities) {
+		t.Errorf("block entities = %v, want %v", blockEntities, wantBlockEntities)
+	}
+	wantEntities := map[string][3]float64{
+		"tile_0 minecraft:pig": {9.5, 1, 3.5},
+		"tile_3 minecraft:pig": {1.5, 1, 15.5},
+	}
+	if !reflect.DeepEqual(entities, wantEntities) {
+		t.Errorf("entities = %v, want %v", entities, wantEntities)
+	}
+}
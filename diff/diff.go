@@ -0,0 +1,538 @@
+// Package diff compares two slime worlds block by block.
+package diff
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+
+	"github.com/emmanuelvlad/slime2schem/converter"
+	"github.com/emmanuelvlad/slime2schem/schematic"
+	"github.com/emmanuelvlad/slime2schem/slime"
+)
+
+// Change kinds.
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// VoidBlock fills the cells of a diff schematic that did not change.
+const VoidBlock = "minecraft:structure_void"
+
+// BlockChange is a single block whose state differs between the two worlds.
+// RelPos has the block's world X and Z, and its Y counted up from the
+// bottom of the lowest section, the world's MinY (-64 for a 1.18+
+// overworld).
+type BlockChange struct {
+	RelPos [3]int `json:"relPos"`
+	Kind   string `json:"kind"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// ObjectChange is an entity or block entity that was added, removed or
+// modified. Fields lists the top-level NBT tags that differ for a change.
+type ObjectChange struct {
+	Id     string     `json:"id"`
+	Kind   string     `json:"kind"`
+	Pos    [3]float64 `json:"pos"`
+	Fields []string   `json:"fields,omitempty"`
+
+	before, after map[string]interface{}
+	// block is the state of a block entity's block in the new world, which
+	// must be pasted along with it.
+	block string
+}
+
+// Summary counts the changes in a Result.
+type Summary struct {
+	BlocksAdded          int `json:"blocksAdded"`
+	BlocksRemoved        int `json:"blocksRemoved"`
+	BlocksChanged        int `json:"blocksChanged"`
+	BlockEntitiesAdded   int `json:"blockEntitiesAdded"`
+	BlockEntitiesRemoved int `json:"blockEntitiesRemoved"`
+	BlockEntitiesChanged int `json:"blockEntitiesChanged"`
+	EntitiesAdded        int `json:"entitiesAdded"`
+	EntitiesRemoved      int `json:"entitiesRemoved"`
+	EntitiesChanged      int `json:"entitiesChanged"`
+}
+
+// Result holds every difference between two worlds.
+type Result struct {
+	Summary       Summary        `json:"summary"`
+	Blocks        []BlockChange  `json:"blocks"`
+	BlockEntities []ObjectChange `json:"blockEntities"`
+	Entities      []ObjectChange `json:"entities"`
+
+	dataVersion uint32
+	minY        int // world Y of RelPos Y 0
+}
+
+// Compare reports the differences needed to turn world a into world b.
+// Chunks and sections missing from one side are treated as air.
+func Compare(a, b *slime.SlimeWorld) *Result {
+	res := &Result{
+		Blocks:        []BlockChange{},
+		BlockEntities: []ObjectChange{},
+		Entities:      []ObjectChange{},
+		dataVersion:   b.WorldVersion,
+		minY:          b.MinY,
+	}
+
+	chunksA := indexChunks(a)
+	chunksB := indexChunks(b)
+
+	keys := make([][2]int32, 0, len(chunksA)+len(chunksB))
+	for k := range chunksA {
+		keys = append(keys, k)
+	}
+	for k := range chunksB {
+		if _, ok := chunksA[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+
+	var entsA, entsB []map[string]interface{}
+	for _, k := range keys {
+		ca, cb := chunksA[k], chunksB[k]
+		res.compareBlocks(k, ca, cb)
+		changes := compareBlockEntities(tileEntities(ca), tileEntities(cb))
+		for i := range changes {
+			if changes[i].after != nil {
+				changes[i].block = blockAt(cb, changes[i].Pos, b.MinY)
+			}
+		}
+		res.BlockEntities = append(res.BlockEntities, changes...)
+		entsA = append(entsA, entities(ca)...)
+		entsB = append(entsB, entities(cb)...)
+	}
+	// Entities can move between chunks, so they are matched across the whole world.
+	res.Entities = compareEntities(entsA, entsB)
+
+	for _, c := range res.Blocks {
+		switch c.Kind {
+		case Added:
+			res.Summary.BlocksAdded++
+		case Removed:
+			res.Summary.BlocksRemoved++
+		default:
+			res.Summary.BlocksChanged++
+		}
+	}
+	countObjects(res.BlockEntities, &res.Summary.BlockEntitiesAdded, &res.Summary.BlockEntitiesRemoved, &res.Summary.BlockEntitiesChanged)
+	countObjects(res.Entities, &res.Summary.EntitiesAdded, &res.Summary.EntitiesRemoved, &res.Summary.EntitiesChanged)
+
+	return res
+}
+
+// Empty reports whether the two worlds were identical.
+func (r *Result) Empty() bool {
+	return r.Summary == Summary{}
+}
+
+func indexChunks(world *slime.SlimeWorld) map[[2]int32]*slime.Chunk {
+	m := make(map[[2]int32]*slime.Chunk, len(world.Chunks))
+	for i := range world.Chunks {
+		c := &world.Chunks[i]
+		m[[2]int32{c.X, c.Z}] = c
+	}
+	return m
+}
+
+func tileEntities(c *slime.Chunk) []map[string]interface{} {
+	if c == nil {
+		return nil
+	}
+	return c.TileEntities
+}
+
+func entities(c *slime.Chunk) []map[string]interface{} {
+	if c == nil {
+		return nil
+	}
+	return c.Entities
+}
+
+var emptySection = slime.Section{}
+
+// blockAt returns the state of the block at a world position in c, or "" if
+// c has no section there.
+func blockAt(c *slime.Chunk, pos [3]float64, minY int) string {
+	y := int(pos[1]) - minY
+	if c == nil || y < 0 || y>>4 >= len(c.Sections) {
+		return ""
+	}
+	bs := c.Sections[y>>4].GetBlockAt(int(pos[0])&15, y&15, int(pos[2])&15)
+	return schematic.BlockStateString(bs.Name, bs.Properties)
+}
+
+// compareBlocks appends every block that differs within one chunk column.
+func (r *Result) compareBlocks(key [2]int32, a, b *slime.Chunk) {
+	var secA, secB []slime.Section
+	if a != nil {
+		secA = a.Sections
+	}
+	if b != nil {
+		secB = b.Sections
+	}
+
+	for sIdx := 0; sIdx < max(len(secA), len(secB)); sIdx++ {
+		sa, sb := &emptySection, &emptySection
+		if sIdx < len(secA) {
+			sa = &secA[sIdx]
+		}
+		if sIdx < len(secB) {
+			sb = &secB[sIdx]
+		}
+		if sectionsEqual(sa, sb) {
+			continue
+		}
+
+		for y := 0; y < 16; y++ {
+			for z := 0; z < 16; z++ {
+				for x := 0; x < 16; x++ {
+					ba, bb := sa.GetBlockAt(x, y, z), sb.GetBlockAt(x, y, z)
+					if sameState(ba, bb) {
+						continue
+					}
+					kind := Changed
+					if isAir(ba) {
+						kind = Added
+					} else if isAir(bb) {
+						kind = Removed
+					}
+					r.Blocks = append(r.Blocks, BlockChange{
+						RelPos: [3]int{int(key[0])*16 + x, sIdx*16 + y, int(key[1])*16 + z},
+						Kind:   kind,
+						Before: schematic.BlockStateString(ba.Name, ba.Properties),
+						After:  schematic.BlockStateString(bb.Name, bb.Properties),
+					})
+				}
+			}
+		}
+	}
+}
+
+// sectionsEqual is a fast path for untouched sections: identical palettes
+// and packed data always decode to identical blocks.
+func sectionsEqual(a, b *slime.Section) bool {
+	if len(a.BlockPalette) != len(b.BlockPalette) || len(a.BlockStates) != len(b.BlockStates) {
+		return false
+	}
+	for i := range a.BlockPalette {
+		if !sameState(a.BlockPalette[i], b.BlockPalette[i]) {
+			return false
+		}
+	}
+	for i := range a.BlockStates {
+		if a.BlockStates[i] != b.BlockStates[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func sameState(a, b slime.BlockState) bool {
+	if isAir(a) && isAir(b) {
+		return true
+	}
+	if a.Name != b.Name || len(a.Properties) != len(b.Properties) {
+		return false
+	}
+	for k, v := range a.Properties {
+		if bv, ok := b.Properties[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
+
+func isAir(bs slime.BlockState) bool {
+	return bs.Name == "" || bs.Name == "minecraft:air" || bs.Name == "minecraft:cave_air" || bs.Name == "minecraft:void_air"
+}
+
+// compareBlockEntities matches block entities by position.
+func compareBlockEntities(a, b []map[string]interface{}) []ObjectChange {
+	byPos := make(map[[3]int]map[string]interface{}, len(a))
+	for _, te := range a {
+		if pos, ok := blockEntityPos(te); ok {
+			byPos[pos] = te
+		}
+	}
+
+	var out []ObjectChange
+	for _, tb := range b {
+		pos, ok := blockEntityPos(tb)
+		if !ok {
+			continue
+		}
+		ta, found := byPos[pos]
+		delete(byPos, pos)
+		fpos := [3]float64{float64(pos[0]), float64(pos[1]), float64(pos[2])}
+		if !found {
+			out = append(out, ObjectChange{Id: objectId(tb), Kind: Added, Pos: fpos, after: tb})
+			continue
+		}
+		if fields := changedFields(ta, tb); len(fields) > 0 {
+			out = append(out, ObjectChange{Id: objectId(tb), Kind: Changed, Pos: fpos, Fields: fields, before: ta, after: tb})
+		}
+	}
+	for pos, ta := range byPos {
+		fpos := [3]float64{float64(pos[0]), float64(pos[1]), float64(pos[2])}
+		out = append(out, ObjectChange{Id: objectId(ta), Kind: Removed, Pos: fpos, before: ta})
+	}
+	sortObjects(out)
+	return out
+}
+
+// compareEntities matches entities by UUID, falling back to id and position
+// for entities without one.
+func compareEntities(a, b []map[string]interface{}) []ObjectChange {
+	// Several entities may share a fallback key, so keep a queue per key.
+	byKey := make(map[string][]map[string]interface{}, len(a))
+	for _, e := range a {
+		key := entityKey(e)
+		byKey[key] = append(byKey[key], e)
+	}
+
+	var out []ObjectChange
+	for _, eb := range b {
+		key := entityKey(eb)
+		var ea map[string]interface{}
+		found := len(byKey[key]) > 0
+		if found {
+			ea = byKey[key][0]
+			byKey[key] = byKey[key][1:]
+		}
+		pos, _ := entityPos(eb)
+		if !found {
+			out = append(out, ObjectChange{Id: objectId(eb), Kind: Added, Pos: pos, after: eb})
+			continue
+		}
+		if fields := changedFields(ea, eb); len(fields) > 0 {
+			out = append(out, ObjectChange{Id: objectId(eb), Kind: Changed, Pos: pos, Fields: fields, before: ea, after: eb})
+		}
+	}
+	for _, remaining := range byKey {
+		for _, ea := range remaining {
+			pos, _ := entityPos(ea)
+			out = append(out, ObjectChange{Id: objectId(ea), Kind: Removed, Pos: pos, before: ea})
+		}
+	}
+	sortObjects(out)
+	return out
+}
+
+func entityKey(e map[string]interface{}) string {
+	if uuid, ok := e["UUID"].([]int32); ok && len(uuid) == 4 {
+		return fmt.Sprintf("uuid:%08x%08x%08x%08x", uint32(uuid[0]), uint32(uuid[1]), uint32(uuid[2]), uint32(uuid[3]))
+	}
+	pos, _ := entityPos(e)
+	return fmt.Sprintf("%s@%.3f,%.3f,%.3f", objectId(e), pos[0], pos[1], pos[2])
+}
+
+// changedFields lists the top-level NBT tags that differ between a and b.
+func changedFields(a, b map[string]interface{}) []string {
+	var fields []string
+	for k, va := range a {
+		if vb, ok := b[k]; !ok || !reflect.DeepEqual(va, vb) {
+			fields = append(fields, k)
+		}
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			fields = append(fields, k)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func sortObjects(objs []ObjectChange) {
+	sort.SliceStable(objs, func(i, j int) bool {
+		for axis := 0; axis < 3; axis++ {
+			if objs[i].Pos[axis] != objs[j].Pos[axis] {
+				return objs[i].Pos[axis] < objs[j].Pos[axis]
+			}
+		}
+		return objs[i].Id < objs[j].Id
+	})
+}
+
+func countObjects(objs []ObjectChange, added, removed, changed *int) {
+	for _, o := range objs {
+		switch o.Kind {
+		case Added:
+			*added++
+		case Removed:
+			*removed++
+		default:
+			*changed++
+		}
+	}
+}
+
+func objectId(m map[string]interface{}) string {
+	id, _ := m["id"].(string)
+	if id == "" {
+		id, _ = m["Id"].(string)
+	}
+	return id
+}
+
+func blockEntityPos(te map[string]interface{}) ([3]int, bool) {
+	var pos [3]int
+	for i, k := range []string{"x", "y", "z"} {
+		v, ok := toFloat64(te[k])
+		if !ok {
+			return pos, false
+		}
+		pos[i] = int(v)
+	}
+	return pos, true
+}
+
+func entityPos(e map[string]interface{}) ([3]float64, bool) {
+	var pos [3]float64
+	list, ok := e["Pos"].([]interface{})
+	if !ok || len(list) < 3 {
+		return pos, false
+	}
+	for i := range pos {
+		v, ok := toFloat64(list[i])
+		if !ok {
+			return pos, false
+		}
+		pos[i] = v
+	}
+	return pos, true
+}
+
+func toFloat64(v interface{}) (float64, bool) {
+	switch val := v.(type) {
+	case float64:
+		return val, true
+	case float32:
+		return float64(val), true
+	case int16:
+		return float64(val), true
+	case int32:
+		return float64(val), true
+	case int64:
+		return float64(val), true
+	case int:
+		return float64(val), true
+	default:
+		return 0, false
+	}
+}
+
+// Schematic builds a schematic containing only the changed blocks in their
+// new state, plus the added or changed block entities and entities. Removed
+// blocks are air, and the block of every added or changed block entity is
+// included so the block entity pastes with it. Every other cell of the
+// bounding box is VoidBlock, so pasting with a mask that skips VoidBlock
+// (WorldEdit's "//paste -m !structure_void") applies every change and
+// nothing else; structure blocks skip it on their own.
+//
+// The schematic covers every changed block and object, in the frame of
+// BlockChange.RelPos: its offset lines it up with the compared worlds when
+// pasted at X 0, Z 0 and the world's minimum Y. It returns nil if nothing
+// changed.
+func (r *Result) Schematic() *schematic.Schematic {
+	if r.Empty() {
+		return nil
+	}
+
+	lo := [3]int{math.MaxInt, math.MaxInt, math.MaxInt}
+	hi := [3]int{math.MinInt, math.MinInt, math.MinInt}
+	extend := func(pos [3]int) {
+		for axis := range lo {
+			lo[axis] = min(lo[axis], pos[axis])
+			hi[axis] = max(hi[axis], pos[axis])
+		}
+	}
+	for _, c := range r.Blocks {
+		extend(c.RelPos)
+	}
+	for _, objs := range [][]ObjectChange{r.BlockEntities, r.Entities} {
+		for _, o := range objs {
+			extend(r.blockPos(o.Pos))
+		}
+	}
+
+	schem := schematic.NewSchematic(hi[0]-lo[0]+1, hi[1]-lo[1]+1, hi[2]-lo[2]+1, int32(r.dataVersion))
+	schem.Offset = [3]int32{int32(lo[0]), int32(lo[1]), int32(lo[2])}
+
+	for y := 0; y < schem.Height; y++ {
+		for z := 0; z < schem.Length; z++ {
+			for x := 0; x < schem.Width; x++ {
+				schem.SetBlock(x, y, z, VoidBlock)
+			}
+		}
+	}
+	for _, o := range r.BlockEntities {
+		if o.after != nil && o.block != "" {
+			p := r.blockPos(o.Pos)
+			schem.SetBlock(p[0]-lo[0], p[1]-lo[1], p[2]-lo[2], o.block)
+		}
+	}
+	for _, c := range r.Blocks {
+		after := c.After
+		if c.Kind == Removed {
+			after = "minecraft:air"
+		}
+		schem.SetBlock(c.RelPos[0]-lo[0], c.RelPos[1]-lo[1], c.RelPos[2]-lo[2], after)
+	}
+
+	// Object positions, including nested ones, are in world coordinates.
+	shift := [3]int{lo[0], lo[1] + r.minY, lo[2]}
+	for _, o := range r.BlockEntities {
+		if o.after == nil {
+			continue
+		}
+		p := r.blockPos(o.Pos)
+		pos := [3]int32{int32(p[0] - lo[0]), int32(p[1] - lo[1]), int32(p[2] - lo[2])}
+		data := stripKeys(o.after, "id", "Id", "x", "y", "z")
+		converter.RelocateBlockEntityData(data, shift[0], shift[1], shift[2])
+		schem.BlockEntities = append(schem.BlockEntities, schematic.BlockEntity{Pos: pos, Id: o.Id, Data: data})
+	}
+
+	for _, o := range r.Entities {
+		if o.after == nil {
+			continue
+		}
+		pos := [3]float64{o.Pos[0] - float64(shift[0]), o.Pos[1] - float64(shift[1]), o.Pos[2] - float64(shift[2])}
+		data := stripKeys(o.after, "id", "Id", "Pos")
+		converter.RelocateEntityData(data, shift[0], shift[1], shift[2])
+		schem.Entities = append(schem.Entities, schematic.Entity{Pos: pos, Id: o.Id, Data: data})
+	}
+
+	return schem
+}
+
+// blockPos returns the block holding a world position, in the frame of
+// BlockChange.RelPos.
+func (r *Result) blockPos(pos [3]float64) [3]int {
+	return [3]int{int(math.Floor(pos[0])), int(math.Floor(pos[1])) - r.minY, int(math.Floor(pos[2]))}
+}
+
+func stripKeys(m map[string]interface{}, keys ...string) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	for _, k := range keys {
+		delete(out, k)
+	}
+	return out
+}
@@ -0,0 +1,130 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/emmanuelvlad/slime2schem/internal/testworld"
+	"github.com/emmanuelvlad/slime2schem/slime"
+)
+
+// testWorld returns a one-chunk world whose section 0 is filled with stone,
+// except for the given blocks.
+func testWorld(blocks map[[3]int]string) *slime.SlimeWorld {
+	return testworld.New(0, slime.Chunk{Sections: []slime.Section{testworld.Section("minecraft:stone", blocks)}})
+}
+
+func TestSchematicLeavesUnchangedBlocksVoid(t *testing.T) {
+	a := testWorld(map[[3]int]string{{4, 2, 4}: "minecraft:dirt"})
+	b := testWorld(map[[3]int]string{{1, 2, 1}: "minecraft:gold_block", {4, 2, 4}: "minecraft:air"})
+
+	res := Compare(a, b)
+	if s := res.Summary; s.BlocksChanged != 1 || s.BlocksRemoved != 1 || s.BlocksAdded != 0 {
+		t.Fatalf("summary = %+v, want 1 changed and 1 removed", s)
+	}
+
+	schem := res.Schematic()
+	if schem == nil {
+		t.Fatal("Schematic() = nil")
+	}
+	if schem.Width != 4 || schem.Height != 1 || schem.Length != 4 {
+		t.Fatalf("size = %dx%dx%d, want 4x1x4", schem.Width, schem.Height, schem.Length)
+	}
+	if schem.Offset != [3]int32{1, 2, 1} {
+		t.Errorf("offset = %v, want [1 2 1]", schem.Offset)
+	}
+
+	want := map[[3]int]string{
+		{0, 0, 0}: "minecraft:gold_block",
+		{3, 0, 3}: "minecraft:air",
+	}
+	for z := 0; z < schem.Length; z++ {
+		for x := 0; x < schem.Width; x++ {
+			expected, ok := want[[3]int{x, 0, z}]
+			if !ok {
+				expected = VoidBlock
+			}
+			if got := schem.GetBlock(x, 0, z); got != expected {
+				t.Errorf("block at %d,0,%d = %s, want %s", x, z, got, expected)
+			}
+		}
+	}
+}
+
+// overworld returns testWorld as the bottom of a 1.18+ overworld, with an
+// empty second section and the given objects.
+func overworld(blocks map[[3]int]string, tileEntities, entities []map[string]interface{}) *slime.SlimeWorld {
+	return testworld.New(testworld.MinY, slime.Chunk{
+		Sections:     []slime.Section{testworld.Section("minecraft:stone", blocks), testworld.Air()},
+		TileEntities: tileEntities,
+		Entities:     entities,
+	})
+}
+
+func TestSchematicPlacesObjectsInBlockFrame(t *testing.T) {
+	chest := func(lock string) map[string]interface{} {
+		return map[string]interface{}{"id": "minecraft:chest", "x": int32(3), "y": int32(-47), "z": int32(3), "Lock": lock}
+	}
+	pig := map[string]interface{}{
+		"id":    "minecraft:pig",
+		"Pos":   []interface{}{5.5, -46.0, 5.5},
+		"Leash": map[string]interface{}{"X": int32(3), "Y": int32(-47), "Z": int32(3)},
+	}
+	a := overworld(nil, []map[string]interface{}{chest("old")}, nil)
+	b := overworld(map[[3]int]string{{0, 15, 0}: "minecraft:gold_block"}, []map[string]interface{}{chest("new")}, []map[string]interface{}{pig})
+
+	res := Compare(a, b)
+	if s := res.Summary; s.BlocksChanged != 1 || s.BlockEntitiesChanged != 1 || s.EntitiesAdded != 1 {
+		t.Fatalf("summary = %+v", s)
+	}
+	if got := res.Blocks[0].RelPos; got != [3]int{0, 15, 0} {
+		t.Errorf("block at %v, want [0 15 0]", got)
+	}
+
+	schem := res.Schematic()
+	// Blocks from RelPos Y 15 (the gold block) to 18 (the pig at Y -46).
+	if schem.Offset != [3]int32{0, 15, 0} || schem.Width != 6 || schem.Height != 4 || schem.Length != 6 {
+		t.Fatalf("offset %v, size %dx%dx%d; want [0 15 0], 6x4x6", schem.Offset, schem.Width, schem.Height, schem.Length)
+	}
+	if len(schem.BlockEntities) != 1 || schem.BlockEntities[0].Pos != [3]int32{3, 2, 3} {
+		t.Errorf("block entities = %+v, want the chest at 3,2,3", schem.BlockEntities)
+	}
+	if len(schem.Entities) != 1 {
+		t.Fatalf("entities = %+v, want the pig", schem.Entities)
+	}
+	e := schem.Entities[0]
+	if e.Pos != [3]float64{5.5, 3, 5.5} {
+		t.Errorf("pig at %v, want [5.5 3 5.5]", e.Pos)
+	}
+	leash, _ := e.Data["Leash"].(map[string]interface{})
+	if leash["X"] != int32(3) || leash["Y"] != int32(2) || leash["Z"] != int32(3) {
+		t.Errorf("leash = %v, want the chest's position 3,2,3", leash)
+	}
+}
+
+func TestSchematicWithOnlyObjectChanges(t *testing.T) {
+	sign := func(text string) []map[string]interface{} {
+		return []map[string]interface{}{{"id": "minecraft:sign", "x": int32(2), "y": int32(-60), "z": int32(7), "Text1": text}}
+	}
+	blocks := map[[3]int]string{{2, 4, 7}: "minecraft:oak_sign"}
+	res := Compare(overworld(blocks, sign("before"), nil), overworld(blocks, sign("after"), nil))
+	if len(res.Blocks) != 0 || res.Summary.BlockEntitiesChanged != 1 {
+		t.Fatalf("summary = %+v, want one changed block entity", res.Summary)
+	}
+	schem := res.Schematic()
+	if schem == nil {
+		t.Fatal("Schematic() = nil for a sign edit")
+	}
+	if schem.Width != 1 || schem.Height != 1 || schem.Length != 1 || schem.Offset != [3]int32{2, 4, 7} {
+		t.Errorf("offset %v, size %dx%dx%d; want [2 4 7], 1x1x1", schem.Offset, schem.Width, schem.Height, schem.Length)
+	}
+	if got := schem.GetBlock(0, 0, 0); got != "minecraft:oak_sign" {
+		t.Errorf("block = %s, want the sign's block", got)
+	}
+	if len(schem.BlockEntities) != 1 || schem.BlockEntities[0].Data["Text1"] != "after" {
+		t.Errorf("block entities = %+v, want the edited sign", schem.BlockEntities)
+	}
+
+	if res := Compare(overworld(nil, sign("same"), nil), overworld(nil, sign("same"), nil)); res.Schematic() != nil {
+		t.Error("Schematic() != nil for identical worlds")
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/emmanuelvlad/slime2schem/diff"
+	"github.com/emmanuelvlad/slime2schem/schematic"
+)
+
+// runDiff implements "slime2schem diff". Like diff(1) it exits with 0 when
+// the worlds are identical, 1 when they differ and 2 on error.
+func runDiff(args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	jsonOut := fs.Bool("json", false, "Print the full diff as JSON instead of a summary")
+	schemOut := fs.String("schem", "", "Write a .schem of the changed blocks, block entities and entities to this path; unchanged blocks in it are structure_void")
+	formatName := fs.String("format", "v3", "Sponge Schematic version for -schem: v3 or v2")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: slime2schem diff [-json] [-schem changes.schem] <old.slime> <new.slime>\n")
+		fmt.Fprintf(os.Stderr, "\nCompares two SlimeWorld files block by block.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	format, err := schematic.ParseFormat(*formatName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitUsage
+	}
+
+	oldWorld, err := readWorld(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitTrouble
+	}
+	newWorld, err := readWorld(fs.Arg(1))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitTrouble
+	}
+
+	result := diff.Compare(oldWorld, newWorld)
+
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(result); err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding diff: %v\n", err)
+			return exitTrouble
+		}
+	} else {
+		printDiffSummary(result)
+	}
+
+	if *schemOut != "" {
+		schem := result.Schematic()
+		if schem == nil {
+			fmt.Fprintf(os.Stderr, "No changes, %s not written\n", *schemOut)
+		} else {
+			schemData, err := schem.SaveFormat(format)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error saving schematic: %v\n", err)
+				return exitTrouble
+			}
+			if err := os.WriteFile(*schemOut, schemData, 0644); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing output file: %v\n", err)
+				return exitTrouble
+			}
+			fmt.Fprintf(os.Stderr, "Changes saved to: %s\n", *schemOut)
+			fmt.Fprintf(os.Stderr, "Paste at 0,%d,0 with //paste -m !structure_void\n", newWorld.MinY)
+		}
+	}
+
+	if result.Empty() {
+		return exitOK
+	}
+	return exitFailure
+}
+
+func printDiffSummary(r *diff.Result) {
+	s := r.Summary
+	if r.Empty() {
+		fmt.Println("Worlds are identical")
+		return
+	}
+	fmt.Printf("Blocks:         +%d -%d ~%d\n", s.BlocksAdded, s.BlocksRemoved, s.BlocksChanged)
+	fmt.Printf("Block entities: +%d -%d ~%d\n", s.BlockEntitiesAdded, s.BlockEntitiesRemoved, s.BlockEntitiesChanged)
+	fmt.Printf("Entities:       +%d -%d ~%d\n", s.EntitiesAdded, s.EntitiesRemoved, s.EntitiesChanged)
+
+	printObjectChanges("Block entity", r.BlockEntities)
+	printObjectChanges("Entity", r.Entities)
+}
+
+func printObjectChanges(label string, changes []diff.ObjectChange) {
+	for _, c := range changes {
+		fmt.Printf("  %s %s %s at (%.1f, %.1f, %.1f)", label, c.Kind, c.Id, c.Pos[0], c.Pos[1], c.Pos[2])
+		if len(c.Fields) > 0 {
+			fmt.Printf(" fields: %v", c.Fields)
+		}
+		fmt.Println()
+	}
+}
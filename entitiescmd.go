@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/emmanuelvlad/slime2schem/anvil"
+	"github.com/emmanuelvlad/slime2schem/converter"
+)
+
+// nbtObject is one entity or block entity printed by "slime2schem entities".
+type nbtObject struct {
+	Kind  string                 `json:"kind"` // converter.KindEntity or converter.KindBlockEntity
+	Id    string                 `json:"id"`
+	Chunk [2]int32               `json:"chunk"`
+	NBT   map[string]interface{} `json:"nbt"`
+}
+
+// runEntities implements "slime2schem entities".
+func runEntities(args []string) int {
+	fs := flag.NewFlagSet("entities", flag.ExitOnError)
+	kind := fs.String("kind", "all", "What to dump: all, entity or block_entity")
+	id := fs.String("id", "", "Only dump objects with this id, e.g. minecraft:chest (the minecraft: prefix is optional)")
+	chunks := fs.String("chunks", "", "Only dump objects in chunks in the inclusive range x1,z1:x2,z2 (chunk coordinates)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: slime2schem entities [-kind all|entity|block_entity] [-id ID] [-chunks x1,z1:x2,z2] <world.slime>\n")
+		fmt.Fprintf(os.Stderr, "\nDumps the NBT of a world's entities and block entities as JSON.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+	switch *kind {
+	case "all", converter.KindEntity, converter.KindBlockEntity:
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown kind %q (expected all, %s or %s)\n", *kind, converter.KindEntity, converter.KindBlockEntity)
+		return exitUsage
+	}
+	if *id != "" && !strings.Contains(*id, ":") {
+		*id = "minecraft:" + *id
+	}
+
+	var opts anvil.ReadOptions
+	if *chunks != "" {
+		r, err := parseChunkRange(*chunks)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitUsage
+		}
+		opts.Chunks = &r
+	}
+
+	world, err := readWorld(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFailure
+	}
+	filterChunks(world, opts)
+
+	objects := []nbtObject{}
+	add := func(k string, chunk [2]int32, list []map[string]interface{}) {
+		if *kind != "all" && *kind != k {
+			return
+		}
+		for _, m := range list {
+			objectId, _ := m["id"].(string)
+			if objectId == "" {
+				objectId, _ = m["Id"].(string)
+			}
+			if *id != "" && objectId != *id {
+				continue
+			}
+			objects = append(objects, nbtObject{Kind: k, Id: objectId, Chunk: chunk, NBT: m})
+		}
+	}
+	for _, c := range world.Chunks {
+		chunk := [2]int32{c.X, c.Z}
+		add(converter.KindBlockEntity, chunk, c.TileEntities)
+		add(converter.KindEntity, chunk, c.Entities)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(objects); err != nil {
+		fmt.Fprintf(os.Stderr, "Error encoding NBT: %v\n", err)
+		return exitFailure
+	}
+	return exitOK
+}
@@ -0,0 +1,392 @@
+// Package gltf exports schematics as glTF 2.0 binary (.glb) meshes, e.g.
+// for 3D previews in a browser.
+//
+// Every block is a unit cube coloured with its map colour (see
+// render.BlockColor). Faces between two opaque blocks are left out, and
+// neighbouring faces of the same colour are merged into larger quads
+// (greedy meshing), so the mesh size follows the build's surface rather
+// than its volume. Water goes in a second, translucent primitive.
+package gltf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"image/color"
+	"math"
+
+	"github.com/emmanuelvlad/slime2schem/render"
+	"github.com/emmanuelvlad/slime2schem/schematic"
+)
+
+// Options controls the export.
+type Options struct {
+	// MinY leaves out blocks below this schematic Y (0 is the bottom
+	// layer), e.g. to cut away the ground under an arena.
+	MinY int
+}
+
+// Block kinds.
+const (
+	kindNone   = iota // air and see-through blocks: not drawn
+	kindOpaque        // drawn, hides faces behind it
+	kindWater         // drawn translucent, hides only other water
+)
+
+// waterAlpha is the opacity of the water material.
+const waterAlpha = 0.6
+
+// block is how a palette entry is meshed.
+type block struct {
+	kind  int
+	color int // index into the mesh's colour list, for greedy merging
+}
+
+// Save meshes s and encodes it as a .glb file. The model is in metres
+// (one block per metre), Y up, with the origin at the centre of the
+// schematic's bottom face.
+func Save(s *schematic.Schematic, opts Options) ([]byte, error) {
+	m := newMesher(s, opts)
+	m.mesh()
+	if len(m.prims[0].indices) == 0 && len(m.prims[1].indices) == 0 {
+		return nil, errors.New("schematic has no visible blocks")
+	}
+	return m.encode()
+}
+
+// primitive holds the vertex data of one material.
+type primitive struct {
+	positions []float32
+	normals   []float32
+	colors    []float32
+	indices   []uint32
+}
+
+type mesher struct {
+	s      *schematic.Schematic
+	minY   int
+	blocks []block
+	// colors lists distinct block colours, in linear RGB.
+	colors [][3]float32
+	prims  [2]primitive // opaque, water
+}
+
+func newMesher(s *schematic.Schematic, opts Options) *mesher {
+	m := &mesher{s: s, minY: max(opts.MinY, 0)}
+	// Blocks missing from the colour table are drawn like stone.
+	fallback, _ := render.BlockColor("minecraft:stone")
+	water, _ := render.BlockColor("minecraft:water")
+	index := make(map[color.NRGBA]int)
+	for _, state := range s.PaletteNames() {
+		c, ok := render.BlockColor(state)
+		if !ok {
+			c = fallback
+		}
+		if c.A == 0 {
+			m.blocks = append(m.blocks, block{kind: kindNone})
+			continue
+		}
+		kind := kindOpaque
+		if c == water {
+			kind = kindWater
+		}
+		i, ok := index[c]
+		if !ok {
+			i = len(m.colors)
+			index[c] = i
+			m.colors = append(m.colors, [3]float32{linear(c.R), linear(c.G), linear(c.B)})
+		}
+		m.blocks = append(m.blocks, block{kind: kind, color: i})
+	}
+	return m
+}
+
+// linear converts an sRGB channel to linear, as glTF vertex colours are.
+func linear(v uint8) float32 {
+	c := float64(v) / 255
+	if c <= 0.04045 {
+		return float32(c / 12.92)
+	}
+	return float32(math.Pow((c+0.055)/1.055, 2.4))
+}
+
+func (m *mesher) at(p [3]int) block {
+	if p[1] < m.minY {
+		return block{}
+	}
+	return m.blocks[m.s.PaletteIndexAt(p[0], p[1], p[2])]
+}
+
+// visible reports whether the face of b towards the block at p is drawn.
+func (m *mesher) visible(b block, p [3]int) bool {
+	if b.kind == kindNone {
+		return false
+	}
+	switch m.at(p).kind {
+	case kindOpaque:
+		return false
+	case kindWater:
+		return b.kind != kindWater
+	}
+	return true
+}
+
+// mesh builds both primitives with greedy meshing: for each axis and
+// direction, every layer of faces is turned into a mask of face ids and
+// covered with as few rectangles as possible.
+func (m *mesher) mesh() {
+	dims := [3]int{m.s.Width, m.s.Height, m.s.Length}
+	for d := 0; d < 3; d++ {
+		u, v := (d+1)%3, (d+2)%3
+		mask := make([]int, dims[u]*dims[v])
+		for _, sign := range []int{-1, 1} {
+			for i := 0; i < dims[d]; i++ {
+				if d == 1 && i < m.minY {
+					continue
+				}
+				// Face ids: 0 for none, else 1 + 2*colour + water.
+				for b := 0; b < dims[v]; b++ {
+					for a := 0; a < dims[u]; a++ {
+						var p [3]int
+						p[d], p[u], p[v] = i, a, b
+						blk := m.at(p)
+						q := p
+						q[d] += sign
+						id := 0
+						if m.visible(blk, q) {
+							id = 1 + 2*blk.color
+							if blk.kind == kindWater {
+								id++
+							}
+						}
+						mask[b*dims[u]+a] = id
+					}
+				}
+				m.greedy(mask, dims, d, u, v, i, sign)
+			}
+		}
+	}
+}
+
+func (m *mesher) greedy(mask []int, dims [3]int, d, u, v, i, sign int) {
+	nu, nv := dims[u], dims[v]
+	for b := 0; b < nv; b++ {
+		for a := 0; a < nu; {
+			id := mask[b*nu+a]
+			if id == 0 {
+				a++
+				continue
+			}
+			w := 1
+			for a+w < nu && mask[b*nu+a+w] == id {
+				w++
+			}
+			h := 1
+		grow:
+			for b+h < nv {
+				for k := 0; k < w; k++ {
+					if mask[(b+h)*nu+a+k] != id {
+						break grow
+					}
+				}
+				h++
+			}
+			for hb := 0; hb < h; hb++ {
+				for k := 0; k < w; k++ {
+					mask[(b+hb)*nu+a+k] = 0
+				}
+			}
+			m.quad(d, u, v, i, sign, a, b, w, h, id)
+			a += w
+		}
+	}
+}
+
+// quad adds the w x h face at layer i of axis d, facing sign, with its
+// corner at (a, b) along axes u and v.
+func (m *mesher) quad(d, u, v, i, sign, a, b, w, h, id int) {
+	water := (id-1)%2 == 1
+	col := m.colors[(id-1)/2]
+	prim := &m.prims[0]
+	if water {
+		prim = &m.prims[1]
+	}
+
+	plane := i
+	if sign > 0 {
+		plane++
+	}
+	corners := [4][2]int{{a, b}, {a + w, b}, {a + w, b + h}, {a, b + h}}
+	if sign < 0 {
+		corners[1], corners[3] = corners[3], corners[1]
+	}
+	var normal [3]float32
+	normal[d] = float32(sign)
+
+	// Centre the model on X and Z, with the bottom of the schematic at 0.
+	offset := [3]float32{float32(m.s.Width) / 2, 0, float32(m.s.Length) / 2}
+	base := uint32(len(prim.positions) / 3)
+	for _, c := range corners {
+		var p [3]int
+		p[d], p[u], p[v] = plane, c[0], c[1]
+		for k := 0; k < 3; k++ {
+			prim.positions = append(prim.positions, float32(p[k])-offset[k])
+		}
+		prim.normals = append(prim.normals, normal[:]...)
+		prim.colors = append(prim.colors, col[:]...)
+	}
+	prim.indices = append(prim.indices, base, base+1, base+2, base, base+2, base+3)
+}
+
+// glTF JSON structures, limited to what the exporter writes.
+type (
+	gltfDoc struct {
+		Asset       gltfAsset        `json:"asset"`
+		Scene       int              `json:"scene"`
+		Scenes      []gltfScene      `json:"scenes"`
+		Nodes       []gltfNode       `json:"nodes"`
+		Meshes      []gltfMesh       `json:"meshes"`
+		Materials   []gltfMaterial   `json:"materials"`
+		Accessors   []gltfAccessor   `json:"accessors"`
+		BufferViews []gltfBufferView `json:"bufferViews"`
+		Buffers     []gltfBuffer     `json:"buffers"`
+	}
+	gltfAsset struct {
+		Version   string `json:"version"`
+		Generator string `json:"generator"`
+	}
+	gltfScene struct {
+		Nodes []int `json:"nodes"`
+	}
+	gltfNode struct {
+		Mesh int `json:"mesh"`
+	}
+	gltfMesh struct {
+		Primitives []gltfPrimitive `json:"primitives"`
+	}
+	gltfPrimitive struct {
+		Attributes map[string]int `json:"attributes"`
+		Indices    int            `json:"indices"`
+		Material   int            `json:"material"`
+	}
+	gltfMaterial struct {
+		Name                 string  `json:"name"`
+		PBRMetallicRoughness gltfPBR `json:"pbrMetallicRoughness"`
+		AlphaMode            string  `json:"alphaMode,omitempty"`
+		DoubleSided          bool    `json:"doubleSided,omitempty"`
+	}
+	gltfPBR struct {
+		BaseColorFactor [4]float32 `json:"baseColorFactor"`
+		MetallicFactor  float32    `json:"metallicFactor"`
+		RoughnessFactor float32    `json:"roughnessFactor"`
+	}
+	gltfAccessor struct {
+		BufferView    int       `json:"bufferView"`
+		ComponentType int       `json:"componentType"`
+		Count         int       `json:"count"`
+		Type          string    `json:"type"`
+		Min           []float32 `json:"min,omitempty"`
+		Max           []float32 `json:"max,omitempty"`
+	}
+	gltfBufferView struct {
+		Buffer     int `json:"buffer"`
+		ByteOffset int `json:"byteOffset"`
+		ByteLength int `json:"byteLength"`
+		Target     int `json:"target"`
+	}
+	gltfBuffer struct {
+		ByteLength int `json:"byteLength"`
+	}
+)
+
+// glTF constants.
+const (
+	componentFloat      = 5126
+	componentUint32     = 5125
+	targetArrayBuffer   = 34962
+	targetElementBuffer = 34963
+	glbMagic            = 0x46546C67 // "glTF"
+	glbVersion          = 2
+	glbChunkJSON        = 0x4E4F534A // "JSON"
+	glbChunkBIN         = 0x004E4942 // "BIN\0"
+	generator           = "slime2schem"
+	materialBlocks      = "blocks"
+	materialWater       = "water"
+)
+
+// encode lays out the primitives' data in one binary buffer and wraps it
+// with the glTF JSON in a .glb container.
+func (m *mesher) encode() ([]byte, error) {
+	doc := gltfDoc{
+		Asset:  gltfAsset{Version: "2.0", Generator: generator},
+		Scenes: []gltfScene{{Nodes: []int{0}}},
+		Nodes:  []gltfNode{{Mesh: 0}},
+		Materials: []gltfMaterial{
+			{Name: materialBlocks, PBRMetallicRoughness: gltfPBR{BaseColorFactor: [4]float32{1, 1, 1, 1}, RoughnessFactor: 1}},
+			{Name: materialWater, PBRMetallicRoughness: gltfPBR{BaseColorFactor: [4]float32{1, 1, 1, waterAlpha}, RoughnessFactor: 0.2}, AlphaMode: "BLEND", DoubleSided: true},
+		},
+		Meshes: []gltfMesh{{}},
+	}
+
+	var bin bytes.Buffer
+	addView := func(data interface{}, target int) int {
+		offset := bin.Len()
+		binary.Write(&bin, binary.LittleEndian, data)
+		doc.BufferViews = append(doc.BufferViews, gltfBufferView{ByteOffset: offset, ByteLength: bin.Len() - offset, Target: target})
+		return len(doc.BufferViews) - 1
+	}
+	addAccessor := func(a gltfAccessor) int {
+		doc.Accessors = append(doc.Accessors, a)
+		return len(doc.Accessors) - 1
+	}
+
+	for material, prim := range m.prims {
+		if len(prim.indices) == 0 {
+			continue
+		}
+		count := len(prim.positions) / 3
+		lo, hi := bounds(prim.positions)
+		attrs := map[string]int{
+			"POSITION": addAccessor(gltfAccessor{BufferView: addView(prim.positions, targetArrayBuffer), ComponentType: componentFloat, Count: count, Type: "VEC3", Min: lo, Max: hi}),
+			"NORMAL":   addAccessor(gltfAccessor{BufferView: addView(prim.normals, targetArrayBuffer), ComponentType: componentFloat, Count: count, Type: "VEC3"}),
+			"COLOR_0":  addAccessor(gltfAccessor{BufferView: addView(prim.colors, targetArrayBuffer), ComponentType: componentFloat, Count: count, Type: "VEC3"}),
+		}
+		indices := addAccessor(gltfAccessor{BufferView: addView(prim.indices, targetElementBuffer), ComponentType: componentUint32, Count: len(prim.indices), Type: "SCALAR"})
+		doc.Meshes[0].Primitives = append(doc.Meshes[0].Primitives, gltfPrimitive{Attributes: attrs, Indices: indices, Material: material})
+	}
+	doc.Buffers = []gltfBuffer{{ByteLength: bin.Len()}}
+
+	js, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	for len(js)%4 != 0 {
+		js = append(js, ' ')
+	}
+	for bin.Len()%4 != 0 {
+		bin.WriteByte(0)
+	}
+
+	var out bytes.Buffer
+	binary.Write(&out, binary.LittleEndian, []uint32{glbMagic, glbVersion, uint32(12 + 8 + len(js) + 8 + bin.Len())})
+	binary.Write(&out, binary.LittleEndian, []uint32{uint32(len(js)), glbChunkJSON})
+	out.Write(js)
+	binary.Write(&out, binary.LittleEndian, []uint32{uint32(bin.Len()), glbChunkBIN})
+	out.Write(bin.Bytes())
+	return out.Bytes(), nil
+}
+
+// bounds returns the per-axis minimum and maximum of VEC3 data, which
+// glTF requires for positions.
+func bounds(vec3 []float32) (lo, hi []float32) {
+	lo = []float32{vec3[0], vec3[1], vec3[2]}
+	hi = []float32{vec3[0], vec3[1], vec3[2]}
+	for i := 3; i < len(vec3); i += 3 {
+		for k := 0; k < 3; k++ {
+			lo[k] = min(lo[k], vec3[i+k])
+			hi[k] = max(hi[k], vec3[i+k])
+		}
+	}
+	return lo, hi
+}
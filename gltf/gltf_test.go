@@ -0,0 +1,62 @@
+package gltf
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/emmanuelvlad/slime2schem/schematic"
+)
+
+// faces meshes s and returns the number of quads in its opaque and water
+// primitives.
+func faces(s *schematic.Schematic, opts Options) (opaque, water int) {
+	m := newMesher(s, opts)
+	m.mesh()
+	return len(m.prims[0].indices) / 6, len(m.prims[1].indices) / 6
+}
+
+func TestGreedyMeshFaces(t *testing.T) {
+	build := func(width, height int, states ...string) *schematic.Schematic {
+		s := schematic.NewSchematic(width, height, 1, 3953)
+		for i, state := range states {
+			s.SetBlock(i%width, i/width, 0, state)
+		}
+		return s
+	}
+	tests := []struct {
+		name          string
+		s             *schematic.Schematic
+		opts          Options
+		opaque, water int
+	}{
+		// Top, bottom, north and south faces of the pair merge: 6 quads.
+		{"2x1x1 of one block", build(2, 1, "minecraft:stone", "minecraft:stone"), Options{}, 6, 0},
+		// Different colours do not merge, and the shared face is hidden.
+		{"2x1x1 of two blocks", build(2, 1, "minecraft:stone", "minecraft:oak_planks"), Options{}, 10, 0},
+		// Water hides nothing behind it, and only its outer faces are drawn.
+		{"water beside stone", build(2, 1, "minecraft:stone", "minecraft:water"), Options{}, 6, 5},
+		{"1x2x1 above MinY", build(1, 2, "minecraft:stone", "minecraft:oak_planks"), Options{MinY: 1}, 6, 0},
+	}
+	for _, tt := range tests {
+		opaque, water := faces(tt.s, tt.opts)
+		if opaque != tt.opaque || water != tt.water {
+			t.Errorf("%s: %d opaque and %d water faces, want %d and %d", tt.name, opaque, water, tt.opaque, tt.water)
+		}
+	}
+}
+
+func TestSave(t *testing.T) {
+	s := schematic.NewSchematic(2, 1, 1, 3953)
+	s.SetBlock(0, 0, 0, "minecraft:stone")
+	data, err := Save(s, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) < 12 || string(data[:4]) != "glTF" || binary.LittleEndian.Uint32(data[4:]) != 2 || int(binary.LittleEndian.Uint32(data[8:])) != len(data) {
+		t.Errorf("header = % x, want glTF version 2 of %d bytes", data[:min(len(data), 12)], len(data))
+	}
+
+	if _, err := Save(s, Options{MinY: 1}); err == nil {
+		t.Error("Save above every block succeeded")
+	}
+}
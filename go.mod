@@ -4,5 +4,8 @@ go 1.25.1
 
 require (
 	github.com/Tnze/go-mc v1.20.2
+	github.com/fsnotify/fsnotify v1.8.0
 	github.com/klauspost/compress v1.18.4
 )
+
+require golang.org/x/sys v0.13.0 // indirect
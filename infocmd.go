@@ -0,0 +1,312 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/emmanuelvlad/slime2schem/slime"
+)
+
+// worldInfo is the output of "slime2schem info".
+type worldInfo struct {
+	File          string       `json:"file"`
+	Format        string       `json:"format"`
+	DataVersion   uint32       `json:"dataVersion"`
+	Flags         []string     `json:"flags,omitempty"`
+	Chunks        int          `json:"chunks"`
+	Bounds        *chunkBounds `json:"bounds,omitempty"`
+	Sections      int          `json:"sections"` // most sections in one chunk
+	BlockEntities int          `json:"blockEntities"`
+	Entities      int          `json:"entities"`
+	// Sizes of the slime file's data blocks, before and after compression.
+	ChunksCompressed   int         `json:"chunksCompressed,omitempty"`
+	ChunksUncompressed int         `json:"chunksUncompressed,omitempty"`
+	ExtraCompressed    int         `json:"extraCompressed,omitempty"`
+	ExtraUncompressed  int         `json:"extraUncompressed,omitempty"`
+	ChunkSizes         []chunkSize `json:"chunkSizes,omitempty"`
+
+	// With -detailed: bytes per part over all chunks, and per chunk,
+	// heaviest first.
+	Parts        map[string]slime.PartSize `json:"parts,omitempty"`
+	ChunkDetails []chunkDetail             `json:"chunkDetails,omitempty"`
+}
+
+// chunkBounds is an inclusive range of chunk coordinates.
+type chunkBounds struct {
+	MinX int32 `json:"minX"`
+	MinZ int32 `json:"minZ"`
+	MaxX int32 `json:"maxX"`
+	MaxZ int32 `json:"maxZ"`
+}
+
+// chunkSize is the uncompressed size of one chunk in a slime file.
+type chunkSize struct {
+	X    int32 `json:"x"`
+	Z    int32 `json:"z"`
+	Size int   `json:"size"`
+}
+
+// chunkDetail is the size breakdown of one chunk, by part name.
+type chunkDetail struct {
+	X            int32                     `json:"x"`
+	Z            int32                     `json:"z"`
+	Uncompressed int                       `json:"uncompressed"`
+	Compressed   int                       `json:"compressed"`
+	Parts        map[string]slime.PartSize `json:"parts"`
+}
+
+// runInfo implements "slime2schem info".
+func runInfo(args []string) int {
+	fs := flag.NewFlagSet("info", flag.ExitOnError)
+	jsonOut := fs.Bool("json", false, "Print the information as JSON")
+	top := fs.Int("top", 10, "Number of chunks to list by size (0 for all)")
+	detailed := fs.Bool("detailed", false, "Break down chunk sizes by part: sections, light, biomes, heightmaps, POI, ticks, block entities, entities and PDC (slime files only)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: slime2schem info [-json] [-top N] [-detailed] <world.slime>\n")
+		fmt.Fprintf(os.Stderr, "\nShows a world's format, data version, flags, bounds and chunk sizes.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 || *top < 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	world, err := readWorld(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFailure
+	}
+
+	info := newWorldInfo(fs.Arg(0), world)
+	if *detailed {
+		if world.Header == nil {
+			fmt.Fprintf(os.Stderr, "Error: -detailed needs a .slime file, %s is %s\n", fs.Arg(0), info.Format)
+			return exitUsage
+		}
+		data, err := os.ReadFile(fs.Arg(0))
+		if err == nil {
+			err = info.addDetails(data)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitFailure
+		}
+	}
+	if *top > 0 && len(info.ChunkSizes) > *top {
+		info.ChunkSizes = info.ChunkSizes[:*top]
+	}
+	if *top > 0 && len(info.ChunkDetails) > *top {
+		info.ChunkDetails = info.ChunkDetails[:*top]
+	}
+
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(info); err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding info: %v\n", err)
+			return exitFailure
+		}
+		return exitOK
+	}
+	printWorldInfo(info)
+	return exitOK
+}
+
+func newWorldInfo(path string, world *slime.SlimeWorld) *worldInfo {
+	info := &worldInfo{
+		File:        path,
+		Format:      worldFormat(path, world),
+		DataVersion: world.WorldVersion,
+		Chunks:      len(world.Chunks),
+	}
+	if h := world.Header; h != nil {
+		info.Flags = worldFlagNames(h.Flags)
+		info.ChunksCompressed = h.ChunksCompressed
+		info.ChunksUncompressed = h.ChunksUncompressed
+		info.ExtraCompressed = h.ExtraCompressed
+		info.ExtraUncompressed = h.ExtraUncompressed
+	}
+
+	if len(world.Chunks) > 0 {
+		info.Bounds = &chunkBounds{math.MaxInt32, math.MaxInt32, math.MinInt32, math.MinInt32}
+	}
+	for _, c := range world.Chunks {
+		info.Bounds.MinX, info.Bounds.MaxX = min(info.Bounds.MinX, c.X), max(info.Bounds.MaxX, c.X)
+		info.Bounds.MinZ, info.Bounds.MaxZ = min(info.Bounds.MinZ, c.Z), max(info.Bounds.MaxZ, c.Z)
+		info.Sections = max(info.Sections, len(c.Sections))
+		info.BlockEntities += len(c.TileEntities)
+		info.Entities += len(c.Entities)
+		if c.Size > 0 {
+			info.ChunkSizes = append(info.ChunkSizes, chunkSize{c.X, c.Z, c.Size})
+		}
+	}
+	sort.Slice(info.ChunkSizes, func(i, j int) bool {
+		a, b := info.ChunkSizes[i], info.ChunkSizes[j]
+		if a.Size != b.Size {
+			return a.Size > b.Size
+		}
+		if a.Z != b.Z {
+			return a.Z < b.Z
+		}
+		return a.X < b.X
+	})
+	return info
+}
+
+// addDetails adds the per-part size breakdown of the slime file data.
+func (info *worldInfo) addDetails(data []byte) error {
+	_, chunks, err := slime.InspectSlimeWorld(data)
+	if err != nil {
+		return err
+	}
+	var totals [slime.NumParts]slime.PartSize
+	for i := range chunks {
+		c := &chunks[i]
+		d := chunkDetail{
+			X:            c.X,
+			Z:            c.Z,
+			Uncompressed: c.Uncompressed(),
+			Compressed:   c.Compressed(),
+			Parts:        make(map[string]slime.PartSize, slime.NumParts),
+		}
+		for p, size := range c.Parts {
+			d.Parts[slime.PartNames[p]] = size
+			totals[p].Uncompressed += size.Uncompressed
+			totals[p].Compressed += size.Compressed
+		}
+		info.ChunkDetails = append(info.ChunkDetails, d)
+	}
+	info.Parts = make(map[string]slime.PartSize, slime.NumParts)
+	for p, size := range totals {
+		info.Parts[slime.PartNames[p]] = size
+	}
+	sort.Slice(info.ChunkDetails, func(i, j int) bool {
+		a, b := info.ChunkDetails[i], info.ChunkDetails[j]
+		if a.Uncompressed != b.Uncompressed {
+			return a.Uncompressed > b.Uncompressed
+		}
+		if a.Z != b.Z {
+			return a.Z < b.Z
+		}
+		return a.X < b.X
+	})
+	return nil
+}
+
+// worldFormat names the format a world was read from.
+func worldFormat(path string, world *slime.SlimeWorld) string {
+	if world.Header != nil {
+		return fmt.Sprintf("slime v%d", world.Header.Version)
+	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return "anvil"
+	}
+	if strings.EqualFold(filepath.Ext(path), ".polar") {
+		return "polar"
+	}
+	return "unknown"
+}
+
+func worldFlagNames(flags uint8) []string {
+	var names []string
+	for _, f := range []struct {
+		bit  uint8
+		name string
+	}{
+		{slime.FlagPOIChunks, "poi_chunks"},
+		{slime.FlagFluidTicks, "fluid_ticks"},
+		{slime.FlagBlockTicks, "block_ticks"},
+	} {
+		if flags&f.bit != 0 {
+			names = append(names, f.name)
+		}
+	}
+	return names
+}
+
+func printWorldInfo(info *worldInfo) {
+	fmt.Printf("File:           %s\n", info.File)
+	fmt.Printf("Format:         %s\n", info.Format)
+	fmt.Printf("Data version:   %d\n", info.DataVersion)
+	if strings.HasPrefix(info.Format, "slime") {
+		flags := "none"
+		if len(info.Flags) > 0 {
+			flags = strings.Join(info.Flags, ", ")
+		}
+		fmt.Printf("Flags:          %s\n", flags)
+	}
+	fmt.Printf("Chunks:         %d\n", info.Chunks)
+	if b := info.Bounds; b != nil {
+		fmt.Printf("Bounds:         chunks X=[%d, %d] Z=[%d, %d], %d sections (%d x %d x %d blocks)\n",
+			b.MinX, b.MaxX, b.MinZ, b.MaxZ, info.Sections,
+			(b.MaxX-b.MinX+1)*16, info.Sections*16, (b.MaxZ-b.MinZ+1)*16)
+	}
+	fmt.Printf("Block entities: %d\n", info.BlockEntities)
+	fmt.Printf("Entities:       %d\n", info.Entities)
+	if info.ChunksUncompressed > 0 {
+		fmt.Printf("Chunk data:     %d bytes (%d uncompressed)\n", info.ChunksCompressed, info.ChunksUncompressed)
+		fmt.Printf("Extra data:     %d bytes (%d uncompressed)\n", info.ExtraCompressed, info.ExtraUncompressed)
+	}
+	if info.Parts != nil {
+		printChunkDetails(info)
+		return
+	}
+	if len(info.ChunkSizes) > 0 {
+		fmt.Printf("\nLargest chunks (uncompressed bytes):\n")
+		for _, c := range info.ChunkSizes {
+			fmt.Printf("  %6d %6d  %d\n", c.X, c.Z, c.Size)
+		}
+	}
+}
+
+// printChunkDetails prints the per-part totals and the heaviest chunks'
+// breakdown, one column per part.
+func printChunkDetails(info *worldInfo) {
+	total := slime.PartSize{}
+	for _, size := range info.Parts {
+		total.Uncompressed += size.Uncompressed
+		total.Compressed += size.Compressed
+	}
+	width := max(len("uncompressed"), len(strconv.Itoa(total.Uncompressed)))
+	fmt.Printf("\nBytes per part (compressed sizes are estimates, each part compressed on its own):\n")
+	fmt.Printf("  %-12s  %*s  %*s  %6s\n", "part", width, "uncompressed", width, "compressed", "share")
+	for _, name := range slime.PartNames {
+		size := info.Parts[name]
+		fmt.Printf("  %-12s  %*d  %*d  %5.1f%%\n", name, width, size.Uncompressed, width, size.Compressed,
+			percent(size.Uncompressed, total.Uncompressed))
+	}
+	fmt.Printf("  %-12s  %*d  %*d\n", "total", width, total.Uncompressed, width, total.Compressed)
+
+	if len(info.ChunkDetails) == 0 {
+		return
+	}
+	fmt.Printf("\nHeaviest chunks (uncompressed bytes):\n")
+	fmt.Printf("  %6s %6s  %8s", "X", "Z", "total")
+	for _, name := range slime.PartNames {
+		fmt.Printf("  %*s", max(len(name), 6), name)
+	}
+	fmt.Println()
+	for _, d := range info.ChunkDetails {
+		fmt.Printf("  %6d %6d  %8d", d.X, d.Z, d.Uncompressed)
+		for _, name := range slime.PartNames {
+			fmt.Printf("  %*d", max(len(name), 6), d.Parts[name].Uncompressed)
+		}
+		fmt.Println()
+	}
+}
+
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) * 100 / float64(total)
+}
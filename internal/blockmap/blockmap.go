@@ -0,0 +1,81 @@
+// Package blockmap looks up block state translation tables, such as the
+// embedded legacy and Bedrock mappings used by the exporters.
+//
+// A table is plain text with one rule per line: a block state pattern, then
+// whitespace, then a target whose syntax is up to the caller. A state
+// matches a pattern when it has the same name and every property the
+// pattern lists; properties the pattern omits are ignored. The first
+// matching rule wins, so specific patterns go before general ones. Blank
+// lines and lines starting with # are skipped.
+package blockmap
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/emmanuelvlad/slime2schem/schematic"
+)
+
+// Table is a parsed translation table with targets of type T.
+type Table[T any] struct {
+	rules map[string][]rule[T]
+}
+
+type rule[T any] struct {
+	properties map[string]string
+	target     T
+}
+
+// Parse parses a table, converting each target with parseTarget. name is
+// used in error messages.
+func Parse[T any](name, text string, parseTarget func(string) (T, error)) (*Table[T], error) {
+	t := &Table[T]{rules: make(map[string][]rule[T])}
+	for n, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		pattern, target, ok := strings.Cut(line, " ")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected \"<block state> <target>\"", name, n+1)
+		}
+		value, err := parseTarget(strings.TrimSpace(target))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", name, n+1, err)
+		}
+		blockName, props := schematic.ParseBlockState(pattern)
+		t.rules[blockName] = append(t.rules[blockName], rule[T]{properties: props, target: value})
+	}
+	return t, nil
+}
+
+// MustParse is like Parse but panics on error. It is meant for tables
+// embedded in the binary.
+func MustParse[T any](name, text string, parseTarget func(string) (T, error)) *Table[T] {
+	t, err := Parse(name, text, parseTarget)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Lookup returns the target of the first rule matching a block state string
+// (as built by schematic.BlockStateString).
+func (t *Table[T]) Lookup(state string) (target T, ok bool) {
+	name, props := schematic.ParseBlockState(state)
+	for _, r := range t.rules[name] {
+		if matches(r.properties, props) {
+			return r.target, true
+		}
+	}
+	return target, false
+}
+
+func matches(want, have map[string]string) bool {
+	for k, v := range want {
+		if have[k] != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,327 @@
+// Package nbtenc writes NBT by hand, tag by tag.
+//
+// It exists alongside go-mc/nbt for two reasons: large payloads (schematic
+// block data) can be streamed without building the whole tree in memory, and
+// compound keys are always written in sorted order so the same data produces
+// the same bytes. Both Java (big-endian) and Bedrock (little-endian) byte
+// orders are supported.
+package nbtenc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// NBT tag type IDs
+const (
+	TagEnd       = 0
+	TagByte      = 1
+	TagShort     = 2
+	TagInt       = 3
+	TagLong      = 4
+	TagFloat     = 5
+	TagDouble    = 6
+	TagByteArray = 7
+	TagString    = 8
+	TagList      = 9
+	TagCompound  = 10
+	TagIntArray  = 11
+	TagLongArray = 12
+)
+
+// Writer writes raw NBT tags to an io.Writer.
+// It tracks the first error and skips subsequent writes once one occurs.
+type Writer struct {
+	w     io.Writer
+	order binary.ByteOrder
+	err   error
+}
+
+// NewWriter returns a Writer producing Java Edition (big-endian) NBT.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w, order: binary.BigEndian}
+}
+
+// NewLittleEndianWriter returns a Writer producing Bedrock Edition
+// (little-endian) NBT.
+func NewLittleEndianWriter(w io.Writer) *Writer {
+	return &Writer{w: w, order: binary.LittleEndian}
+}
+
+// Err returns the first error encountered, if any.
+func (w *Writer) Err() error {
+	return w.err
+}
+
+// Fail records err unless an earlier error is already recorded.
+func (w *Writer) Fail(err error) {
+	if w.err == nil {
+		w.err = err
+	}
+}
+
+// Write writes raw bytes.
+func (w *Writer) Write(data []byte) {
+	if w.err != nil {
+		return
+	}
+	_, w.err = w.w.Write(data)
+}
+
+// WriteNumber writes a fixed-size number (or slice of numbers) in the
+// writer's byte order.
+func (w *Writer) WriteNumber(v any) {
+	if w.err != nil {
+		return
+	}
+	w.err = binary.Write(w.w, w.order, v)
+}
+
+// WriteTagHeader writes a tag type and name.
+func (w *Writer) WriteTagHeader(tagType byte, name string) {
+	w.Write([]byte{tagType})
+	w.writeStringPayload(name)
+}
+
+// BeginCompound opens a named compound. Close it with EndCompound.
+func (w *Writer) BeginCompound(name string) {
+	w.WriteTagHeader(TagCompound, name)
+}
+
+// EndCompound closes the current compound (or compound list element).
+func (w *Writer) EndCompound() {
+	w.Write([]byte{TagEnd})
+}
+
+// BeginList writes the header of a named List tag. The caller writes n
+// element payloads; for compound elements that means the fields followed
+// by EndCompound.
+func (w *Writer) BeginList(name string, elemType byte, n int) {
+	w.WriteTagHeader(TagList, name)
+	w.Write([]byte{elemType})
+	w.WriteNumber(int32(n))
+}
+
+func (w *Writer) WriteInt8(name string, v int8) {
+	w.WriteTagHeader(TagByte, name)
+	w.WriteNumber(v)
+}
+
+func (w *Writer) WriteShort(name string, v int16) {
+	w.WriteTagHeader(TagShort, name)
+	w.WriteNumber(v)
+}
+
+func (w *Writer) WriteInt(name string, v int32) {
+	w.WriteTagHeader(TagInt, name)
+	w.WriteNumber(v)
+}
+
+func (w *Writer) WriteLong(name string, v int64) {
+	w.WriteTagHeader(TagLong, name)
+	w.WriteNumber(v)
+}
+
+func (w *Writer) WriteFloat(name string, v float32) {
+	w.WriteTagHeader(TagFloat, name)
+	w.WriteNumber(v)
+}
+
+func (w *Writer) WriteDouble(name string, v float64) {
+	w.WriteTagHeader(TagDouble, name)
+	w.WriteNumber(v)
+}
+
+func (w *Writer) WriteString(name string, v string) {
+	w.WriteTagHeader(TagString, name)
+	w.writeStringPayload(v)
+}
+
+func (w *Writer) WriteByteArray(name string, v []byte) {
+	w.WriteTagHeader(TagByteArray, name)
+	w.WriteNumber(int32(len(v)))
+	w.Write(v)
+}
+
+func (w *Writer) WriteIntArray(name string, v []int32) {
+	w.WriteTagHeader(TagIntArray, name)
+	w.WriteNumber(int32(len(v)))
+	w.WriteNumber(v)
+}
+
+func (w *Writer) WriteLongArray(name string, v []int64) {
+	w.WriteTagHeader(TagLongArray, name)
+	w.WriteNumber(int32(len(v)))
+	w.WriteNumber(v)
+}
+
+func (w *Writer) writeStringPayload(v string) {
+	w.WriteNumber(uint16(len(v)))
+	w.Write([]byte(v))
+}
+
+// WriteTag writes an arbitrary NBT value (as decoded by go-mc/nbt into
+// interface{}) as a named tag. Compound keys are written in sorted order so
+// that the same data always produces the same bytes.
+func (w *Writer) WriteTag(name string, v interface{}) {
+	if w.err != nil {
+		return
+	}
+	tagType, err := TagType(v)
+	if err != nil {
+		w.err = fmt.Errorf("tag %q: %w", name, err)
+		return
+	}
+	w.WriteTagHeader(tagType, name)
+	w.WritePayload(v)
+}
+
+// WriteFields writes the entries of a compound into the currently open
+// compound in sorted order, skipping any keys listed in skip.
+func (w *Writer) WriteFields(data map[string]interface{}, skip ...string) {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		if !contains(skip, k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		w.WriteTag(k, data[k])
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// TagType returns the NBT tag type used to encode v.
+func TagType(v interface{}) (byte, error) {
+	switch v.(type) {
+	case int8, uint8, bool:
+		return TagByte, nil
+	case int16:
+		return TagShort, nil
+	case int32, int:
+		return TagInt, nil
+	case int64:
+		return TagLong, nil
+	case float32:
+		return TagFloat, nil
+	case float64:
+		return TagDouble, nil
+	case string:
+		return TagString, nil
+	case []byte, []int8:
+		return TagByteArray, nil
+	case []int32:
+		return TagIntArray, nil
+	case []int64:
+		return TagLongArray, nil
+	case []interface{}, []map[string]interface{}, []string:
+		return TagList, nil
+	case map[string]interface{}, map[string]string:
+		return TagCompound, nil
+	default:
+		return TagEnd, fmt.Errorf("unsupported NBT value type %T", v)
+	}
+}
+
+// WritePayload writes the payload of v without a tag header.
+func (w *Writer) WritePayload(v interface{}) {
+	if w.err != nil {
+		return
+	}
+	switch val := v.(type) {
+	case int8, uint8, int16, int32, int64, float32, float64:
+		w.WriteNumber(val)
+	case bool:
+		if val {
+			w.Write([]byte{1})
+		} else {
+			w.Write([]byte{0})
+		}
+	case int:
+		w.WriteNumber(int32(val))
+	case string:
+		w.writeStringPayload(val)
+	case []byte:
+		w.WriteNumber(int32(len(val)))
+		w.Write(val)
+	case []int8:
+		w.WriteNumber(int32(len(val)))
+		w.WriteNumber(val)
+	case []int32:
+		w.WriteNumber(int32(len(val)))
+		w.WriteNumber(val)
+	case []int64:
+		w.WriteNumber(int32(len(val)))
+		w.WriteNumber(val)
+	case []interface{}:
+		w.writeListPayload(len(val), func(i int) interface{} { return val[i] })
+	case []map[string]interface{}:
+		w.writeListPayload(len(val), func(i int) interface{} { return val[i] })
+	case []string:
+		w.writeListPayload(len(val), func(i int) interface{} { return val[i] })
+	case map[string]interface{}:
+		w.WriteFields(val)
+		w.EndCompound()
+	case map[string]string:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			w.WriteString(k, val[k])
+		}
+		w.EndCompound()
+	default:
+		w.err = fmt.Errorf("unsupported NBT value type %T", v)
+	}
+}
+
+// writeListPayload writes a List payload. The element type is taken from the
+// first element; every element must have the same type.
+func (w *Writer) writeListPayload(n int, elem func(int) interface{}) {
+	if n == 0 {
+		w.Write([]byte{TagEnd})
+		w.WriteNumber(int32(0))
+		return
+	}
+	elemType, err := TagType(elem(0))
+	if err != nil {
+		w.err = err
+		return
+	}
+	w.Write([]byte{elemType})
+	w.WriteNumber(int32(n))
+	for i := 0; i < n; i++ {
+		t, err := TagType(elem(i))
+		if err != nil || t != elemType {
+			w.err = fmt.Errorf("list element %d: mixed or unsupported NBT types (%T)", i, elem(i))
+			return
+		}
+		w.WritePayload(elem(i))
+	}
+}
+
+// Marshal encodes a compound as a complete, unnamed root tag in Java
+// (big-endian) byte order.
+func Marshal(root map[string]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.WriteTag("", root)
+	if w.err != nil {
+		return nil, w.err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,93 @@
+// Package testworld builds small in-memory worlds for the tests of the
+// readers, writers and converters, so each test states only the blocks and
+// objects it is about.
+package testworld
+
+import (
+	"slices"
+
+	"github.com/emmanuelvlad/slime2schem/schematic"
+	"github.com/emmanuelvlad/slime2schem/slime"
+)
+
+// DataVersion is the data version of the worlds built by New (1.21).
+const DataVersion = 3953
+
+// MinY is the lowest block Y of a 1.18+ overworld.
+const MinY = -64
+
+// New returns a world holding chunks whose section 0 is at block Y minY.
+func New(minY int, chunks ...slime.Chunk) *slime.SlimeWorld {
+	return &slime.SlimeWorld{WorldVersion: DataVersion, MinY: minY, Chunks: chunks}
+}
+
+// Section returns a section filled with the block state fill, except for the
+// block states given by their position within the section. States are
+// written as in a schematic palette, e.g. "minecraft:oak_stairs[half=top]".
+func Section(fill string, blocks map[[3]int]string) slime.Section {
+	var others []string
+	for _, name := range blocks {
+		if name != fill {
+			others = append(others, name)
+		}
+	}
+	slices.Sort(others)
+	names := append([]string{fill}, slices.Compact(others)...)
+
+	palette := make([]slime.BlockState, len(names))
+	index := make(map[string]int, len(names))
+	for i, state := range names {
+		name, props := schematic.ParseBlockState(state)
+		palette[i] = slime.BlockState{Name: name, Properties: props}
+		index[state] = i
+	}
+	section := slime.NewSection(palette, nil)
+	if len(palette) == 1 {
+		return section
+	}
+	bits := section.BitsPerBlock
+	perLong := 64 / bits
+	section.BlockStates = make([]int64, (4096+perLong-1)/perLong)
+	for pos, state := range blocks {
+		i := pos[1]*256 + pos[2]*16 + pos[0]
+		section.BlockStates[i/perLong] |= int64(index[state]) << (i % perLong * bits)
+	}
+	return section
+}
+
+// Air returns a section of air.
+func Air() slime.Section {
+	return Section("minecraft:air", nil)
+}
+
+// Floor returns a section of air with a layer of the block state name at
+// its bottom.
+func Floor(name string) slime.Section {
+	blocks := make(map[[3]int]string, 256)
+	for z := 0; z < 16; z++ {
+		for x := 0; x < 16; x++ {
+			blocks[[3]int{x, 0, z}] = name
+		}
+	}
+	return Section("minecraft:air", blocks)
+}
+
+// Chest returns a chest block entity at x, y, z.
+func Chest(x, y, z int32) map[string]interface{} {
+	return map[string]interface{}{"id": "minecraft:chest", "x": x, "y": y, "z": z}
+}
+
+// Pig returns a pig entity at x, y, z.
+func Pig(x, y, z float64) map[string]interface{} {
+	return map[string]interface{}{"id": "minecraft:pig", "Pos": []interface{}{x, y, z}}
+}
+
+// Overworld returns a chunk at 0,0 of an overworld: a stone floor at Y -48,
+// the bottom of its second section, with a chest and a pig standing on it.
+func Overworld() *slime.SlimeWorld {
+	return New(MinY, slime.Chunk{
+		Sections:     []slime.Section{Air(), Floor("minecraft:stone")},
+		TileEntities: []map[string]interface{}{Chest(3, -47, 4)},
+		Entities:     []map[string]interface{}{Pig(2.5, -47, 2.5)},
+	})
+}
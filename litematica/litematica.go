@@ -0,0 +1,183 @@
+// Package litematica exports converted builds as Litematica (.litematic)
+// schematics.
+package litematica
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"math/bits"
+	"time"
+
+	"github.com/emmanuelvlad/slime2schem/internal/nbtenc"
+	"github.com/emmanuelvlad/slime2schem/schematic"
+)
+
+// Litematica schematic format version written by Save.
+const (
+	Version    = 6
+	SubVersion = 1
+)
+
+// Options holds the metadata stored in a .litematic file.
+type Options struct {
+	Name        string
+	Description string
+	// Author is left out of the file if empty.
+	Author string
+	// RegionName names the single region; defaults to Name, then "Main".
+	RegionName string
+	// TimeCreated defaults to the current time.
+	TimeCreated time.Time
+}
+
+// Save encodes the schematic as a gzipped .litematic file with a single
+// region covering the whole volume. It must be called before
+// schematic.Schematic.Save, which releases the block data.
+func Save(s *schematic.Schematic, opts Options) ([]byte, error) {
+	if opts.TimeCreated.IsZero() {
+		opts.TimeCreated = time.Now()
+	}
+	regionName := opts.RegionName
+	if regionName == "" {
+		regionName = opts.Name
+	}
+	if regionName == "" {
+		regionName = "Main"
+	}
+
+	totalBlocks := 0
+	for _, n := range s.BlockCounts() {
+		totalBlocks += n
+	}
+	created := opts.TimeCreated.UnixMilli()
+
+	var gzBuf bytes.Buffer
+	gzWriter := gzip.NewWriter(&gzBuf)
+	w := nbtenc.NewWriter(gzWriter)
+
+	w.BeginCompound("")
+	w.WriteInt("MinecraftDataVersion", s.DataVersion)
+	w.WriteInt("Version", Version)
+	w.WriteInt("SubVersion", SubVersion)
+
+	w.BeginCompound("Metadata")
+	w.WriteString("Name", opts.Name)
+	if opts.Author != "" {
+		w.WriteString("Author", opts.Author)
+	}
+	w.WriteString("Description", opts.Description)
+	w.WriteInt("RegionCount", 1)
+	w.WriteInt("TotalBlocks", int32(totalBlocks))
+	w.WriteInt("TotalVolume", int32(s.Width*s.Height*s.Length))
+	w.WriteLong("TimeCreated", created)
+	w.WriteLong("TimeModified", created)
+	writeVec(w, "EnclosingSize", s.Width, s.Height, s.Length)
+	w.EndCompound() // Metadata
+
+	w.BeginCompound("Regions")
+	w.BeginCompound(regionName)
+	writeVec(w, "Position", 0, 0, 0)
+	writeVec(w, "Size", s.Width, s.Height, s.Length)
+
+	palette := s.PaletteNames()
+	w.BeginList("BlockStatePalette", nbtenc.TagCompound, len(palette))
+	for _, state := range palette {
+		name, props := schematic.ParseBlockState(state)
+		w.WriteString("Name", name)
+		if len(props) > 0 {
+			w.WriteTag("Properties", props)
+		}
+		w.EndCompound()
+	}
+	w.WriteLongArray("BlockStates", packBlockStates(s))
+
+	w.BeginList("TileEntities", nbtenc.TagCompound, len(s.BlockEntities))
+	for _, be := range s.BlockEntities {
+		w.WriteInt("x", be.Pos[0])
+		w.WriteInt("y", be.Pos[1])
+		w.WriteInt("z", be.Pos[2])
+		w.WriteString("id", be.Id)
+		w.WriteFields(be.Data, "x", "y", "z", "id")
+		w.EndCompound()
+	}
+
+	w.BeginList("Entities", nbtenc.TagCompound, len(s.Entities))
+	for _, e := range s.Entities {
+		w.WriteTag("Pos", []interface{}{e.Pos[0], e.Pos[1], e.Pos[2]})
+		w.WriteString("id", e.Id)
+		w.WriteFields(e.Data, "Pos", "id")
+		w.EndCompound()
+	}
+
+	writeTicks(w, "PendingBlockTicks", "Block", s.BlockTicks)
+	writeTicks(w, "PendingFluidTicks", "Fluid", s.FluidTicks)
+
+	w.EndCompound() // region
+	w.EndCompound() // Regions
+	w.EndCompound() // root
+
+	if w.Err() != nil {
+		return nil, fmt.Errorf("encoding litematic NBT: %w", w.Err())
+	}
+	if err := gzWriter.Close(); err != nil {
+		return nil, fmt.Errorf("closing gzip writer: %w", err)
+	}
+	return gzBuf.Bytes(), nil
+}
+
+func writeVec(w *nbtenc.Writer, name string, x, y, z int) {
+	w.BeginCompound(name)
+	w.WriteInt("x", int32(x))
+	w.WriteInt("y", int32(y))
+	w.WriteInt("z", int32(z))
+	w.EndCompound()
+}
+
+func writeTicks(w *nbtenc.Writer, name, idKey string, ticks []schematic.Tick) {
+	w.BeginList(name, nbtenc.TagCompound, len(ticks))
+	for i, t := range ticks {
+		w.WriteString(idKey, t.Id)
+		w.WriteInt("Priority", t.Priority)
+		w.WriteLong("SubTick", int64(i))
+		w.WriteInt("Time", t.Delay)
+		w.WriteInt("x", t.Pos[0])
+		w.WriteInt("y", t.Pos[1])
+		w.WriteInt("z", t.Pos[2])
+		w.EndCompound()
+	}
+}
+
+// packBlockStates packs palette indices into longs the way Litematica's
+// LitematicaBitArray does: entries are laid out back to back and may span
+// two longs, at max(2, ceil(log2(palette size))) bits each. Blocks are
+// ordered x, then z, then y.
+func packBlockStates(s *schematic.Schematic) []int64 {
+	bitsPerEntry := max(2, bits.Len(uint(len(s.PaletteNames())-1)))
+	total := s.Width * s.Height * s.Length
+	out := make([]uint64, (total*bitsPerEntry+63)/64)
+
+	i := 0
+	for y := 0; y < s.Height; y++ {
+		for z := 0; z < s.Length; z++ {
+			for x := 0; x < s.Width; x++ {
+				v := uint64(s.PaletteIndexAt(x, y, z))
+				start := i * bitsPerEntry
+				startLong := start >> 6
+				endLong := ((i+1)*bitsPerEntry - 1) >> 6
+				offset := uint(start & 63)
+				out[startLong] |= v << offset
+				if startLong != endLong {
+					out[endLong] |= v >> (64 - offset)
+				}
+				i++
+			}
+		}
+	}
+
+	packed := make([]int64, len(out))
+	for j, v := range out {
+		packed[j] = int64(v)
+	}
+	return packed
+}
@@ -0,0 +1,153 @@
+package litematica
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+	"time"
+
+	"github.com/Tnze/go-mc/nbt"
+
+	"github.com/emmanuelvlad/slime2schem/schematic"
+)
+
+// testSchematic returns a 7x3x5 schematic with five block states, so
+// entries take 3 bits and some span two longs.
+func testSchematic() *schematic.Schematic {
+	s := schematic.NewSchematic(7, 3, 5, 3953)
+	states := []string{"minecraft:stone", "minecraft:dirt", "minecraft:oak_stairs[facing=east,half=top]", "minecraft:glass"}
+	for y := 0; y < s.Height; y++ {
+		for z := 0; z < s.Length; z++ {
+			for x := 0; x < s.Width; x++ {
+				if i := (x + 2*z + 3*y) % 5; i > 0 {
+					s.SetBlock(x, y, z, states[i-1])
+				}
+			}
+		}
+	}
+	return s
+}
+
+// unpackAt reads entry i the way LitematicaBitArray.getAt does.
+func unpackAt(longs []int64, bitsPerEntry, i int) int {
+	start := i * bitsPerEntry
+	startLong, endLong := start>>6, ((i+1)*bitsPerEntry-1)>>6
+	offset := uint(start & 63)
+	mask := uint64(1)<<bitsPerEntry - 1
+	if startLong == endLong {
+		return int(uint64(longs[startLong]) >> offset & mask)
+	}
+	return int((uint64(longs[startLong])>>offset | uint64(longs[endLong])<<(64-offset)) & mask)
+}
+
+func TestPackBlockStates(t *testing.T) {
+	s := testSchematic()
+	packed := packBlockStates(s)
+	const bitsPerEntry = 3
+	if want := (s.Width*s.Height*s.Length*bitsPerEntry + 63) / 64; len(packed) != want {
+		t.Fatalf("%d longs, want %d", len(packed), want)
+	}
+	i := 0
+	for y := 0; y < s.Height; y++ {
+		for z := 0; z < s.Length; z++ {
+			for x := 0; x < s.Width; x++ {
+				if got, want := unpackAt(packed, bitsPerEntry, i), s.PaletteIndexAt(x, y, z); got != want {
+					t.Fatalf("entry %d (%d,%d,%d) = %d, want %d", i, x, y, z, got, want)
+				}
+				i++
+			}
+		}
+	}
+}
+
+func TestSave(t *testing.T) {
+	s := testSchematic()
+	s.BlockEntities = []schematic.BlockEntity{{Pos: [3]int32{1, 2, 3}, Id: "minecraft:chest", Data: map[string]interface{}{"Lock": "key"}}}
+	created := time.UnixMilli(1700000000000)
+	data, err := Save(s, Options{Name: "house", Author: "alex", TimeCreated: created})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file struct {
+		MinecraftDataVersion int32
+		Version              int32
+		Metadata             struct {
+			Name, Author string
+			RegionCount  int32
+			TotalBlocks  int32
+			TotalVolume  int32
+			TimeCreated  int64
+		}
+		Regions map[string]struct {
+			BlockStatePalette []struct {
+				Name       string
+				Properties map[string]string
+			}
+			BlockStates  []int64
+			TileEntities []map[string]interface{}
+		}
+	}
+	if _, err := nbt.NewDecoder(gz).Decode(&file); err != nil {
+		t.Fatal(err)
+	}
+
+	m := file.Metadata
+	if m.Name != "house" || m.Author != "alex" || m.RegionCount != 1 || m.TimeCreated != created.UnixMilli() {
+		t.Errorf("metadata = %+v", m)
+	}
+	if m.TotalVolume != 7*3*5 || m.TotalBlocks != 7*3*5*4/5 {
+		t.Errorf("total blocks %d of %d, want %d of %d", m.TotalBlocks, m.TotalVolume, 7*3*5*4/5, 7*3*5)
+	}
+	if file.Version != Version || file.MinecraftDataVersion != 3953 {
+		t.Errorf("version %d, data version %d", file.Version, file.MinecraftDataVersion)
+	}
+
+	region, ok := file.Regions["house"]
+	if !ok {
+		t.Fatalf("regions = %v, want one named house", file.Regions)
+	}
+	if len(region.BlockStatePalette) != 5 {
+		t.Fatalf("palette has %d entries, want 5", len(region.BlockStatePalette))
+	}
+	stairs := region.BlockStatePalette[s.Palette["minecraft:oak_stairs[facing=east,half=top]"]]
+	if stairs.Name != "minecraft:oak_stairs" || stairs.Properties["facing"] != "east" || stairs.Properties["half"] != "top" {
+		t.Errorf("stairs palette entry = %+v", stairs)
+	}
+	if len(region.TileEntities) != 1 || region.TileEntities[0]["id"] != "minecraft:chest" || region.TileEntities[0]["Lock"] != "key" {
+		t.Errorf("tile entities = %v", region.TileEntities)
+	}
+	packed := packBlockStates(s)
+	if len(region.BlockStates) != len(packed) {
+		t.Fatalf("BlockStates has %d longs, want %d", len(region.BlockStates), len(packed))
+	}
+	for i, v := range packed {
+		if region.BlockStates[i] != v {
+			t.Fatalf("BlockStates[%d] = %x, want %x", i, region.BlockStates[i], v)
+		}
+	}
+}
+
+func TestSaveWithoutAuthor(t *testing.T) {
+	data, err := Save(testSchematic(), Options{Name: "house"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file struct {
+		Metadata map[string]interface{}
+	}
+	if _, err := nbt.NewDecoder(gz).Decode(&file); err != nil {
+		t.Fatal(err)
+	}
+	if author, ok := file.Metadata["Author"]; ok {
+		t.Errorf("Author = %q, want none", author)
+	}
+}
@@ -1,80 +1,234 @@
 package main
 
 import (
-	"flag"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
+	"github.com/emmanuelvlad/slime2schem/anvil"
 	"github.com/emmanuelvlad/slime2schem/converter"
+	"github.com/emmanuelvlad/slime2schem/polar"
+	"github.com/emmanuelvlad/slime2schem/schematic"
 	"github.com/emmanuelvlad/slime2schem/slime"
 )
 
-func main() {
-	inputFile := flag.String("input", "", "Path to the .slime file to convert")
-	outputFile := flag.String("output", "", "Path for the output .schem file (default: input name with .schem extension)")
-	flag.Parse()
+// Exit codes shared by every command. diff and validate exit with
+// exitFailure when they find differences or problems, so they report
+// reading or writing errors with exitTrouble instead.
+const (
+	exitOK      = 0
+	exitFailure = 1 // the command failed
+	exitUsage   = 2 // invalid flags or arguments
+	exitTrouble = 3 // diff or validate could not run
+)
+
+// command is a slime2schem subcommand.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) int
+}
 
-	// Allow positional argument as input
-	if *inputFile == "" && flag.NArg() > 0 {
-		*inputFile = flag.Arg(0)
+var commands = []command{
+	{"convert", "Convert a world to a schematic, mesh or voxel model (default)", runConvert},
+	{"batch", "Convert directories, globs and archives of worlds concurrently", runBatch},
+	{"watch", "Reconvert worlds in a directory whenever they change", runWatch},
+	{"info", "Show a world's format, data version, bounds and chunk sizes", runInfo},
+	{"chunks", "List chunks with their section, block entity and entity counts", runChunks},
+	{"entities", "Dump entity and block entity NBT as JSON", runEntities},
+	{"validate", "Check a world for corrupt or inconsistent data", runValidate},
+	{"stats", "Count blocks, entities and block entities", runStats},
+	{"diff", "Compare two worlds", runDiff},
+	{"render", "Draw a top-down map or isometric view as a PNG", runRender},
+	{"slices", "Write a layer-by-layer build guide", runSlices},
+	{"anvil", "Write a world as a vanilla world directory", runAnvil},
+	{"import", "Pack a vanilla or Polar world into a .slime file", runImport},
+	{"polar", "Write a world as a Minestom Polar file", runPolar},
+}
+
+func main() {
+	args := os.Args[1:]
+	if len(args) == 0 {
+		usage()
+		os.Exit(exitUsage)
+	}
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		usage()
+		os.Exit(exitOK)
+	}
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			os.Exit(cmd.run(args[1:]))
+		}
 	}
 
-	if *inputFile == "" {
-		fmt.Fprintf(os.Stderr, "Usage: slime2schem [-input] <file.slime> [-output file.schem]\n")
-		fmt.Fprintf(os.Stderr, "\nConverts a SlimeWorld (.slime) file to Sponge Schematic v3 (.schem) format.\n")
-		fmt.Fprintf(os.Stderr, "The output schematic can be pasted in Minecraft using WorldEdit.\n\n")
-		flag.PrintDefaults()
-		os.Exit(1)
+	// Without a command, the arguments are those of convert, as in
+	// "slime2schem world.slime" or "slime2schem -input world.slime".
+	if !strings.HasPrefix(args[0], "-") {
+		if _, err := os.Stat(args[0]); err != nil {
+			fmt.Fprintf(os.Stderr, "Unknown command or file %q\n\n", args[0])
+			usage()
+			os.Exit(exitUsage)
+		}
 	}
+	os.Exit(runConvert(args))
+}
 
-	if *outputFile == "" {
-		ext := filepath.Ext(*inputFile)
-		base := strings.TrimSuffix(*inputFile, ext)
-		*outputFile = base + ".schem"
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: slime2schem <command> [flags] <arguments>\n")
+	fmt.Fprintf(os.Stderr, "       slime2schem [convert flags] <world.slime|world.polar|world dir> [output]\n")
+	fmt.Fprintf(os.Stderr, "\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-9s %s\n", cmd.name, cmd.summary)
 	}
+	fmt.Fprintf(os.Stderr, "\nRun \"slime2schem <command> -h\" for a command's flags.\n")
+}
 
-	fmt.Printf("Reading slime world: %s\n", *inputFile)
+// readWorld reads a .slime or .polar file, or a vanilla world directory in
+// Anvil format.
+func readWorld(path string) (*slime.SlimeWorld, error) {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		world, err := anvil.ReadWorld(path, anvil.ReadOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("reading anvil world %s: %w", path, err)
+		}
+		return world, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return parseWorld(path, data)
+}
 
-	data, err := os.ReadFile(*inputFile)
+// parseWorld parses the contents of a .slime or .polar file, telling them
+// apart by the extension of name.
+func parseWorld(name string, data []byte) (*slime.SlimeWorld, error) {
+	read := slime.ReadSlimeWorld
+	if strings.EqualFold(filepath.Ext(name), ".polar") {
+		read = polar.ReadWorld
+	}
+	world, err := read(data)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error reading input file: %v\n", err)
-		os.Exit(1)
+		return nil, fmt.Errorf("parsing %s: %w", name, err)
 	}
+	return world, nil
+}
 
-	world, err := slime.ReadSlimeWorld(data)
+// writeReport encodes the conversion report as indented JSON to path,
+// or to stdout if path is "-".
+func writeReport(path string, report *exportReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing slime world: %v\n", err)
-		os.Exit(1)
+		return err
+	}
+	data = append(data, '\n')
+	if path == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// parseTileSpec parses a tile size given as "N" (same size on every axis)
+// or "WxHxL".
+func parseTileSpec(spec string) (converter.TileOptions, error) {
+	parts := strings.Split(spec, "x")
+	if len(parts) != 1 && len(parts) != 3 {
+		return converter.TileOptions{}, fmt.Errorf("invalid tile size %q (expected N or WxHxL)", spec)
+	}
+	sizes := make([]int, len(parts))
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n <= 0 || n > converter.MaxTileSize {
+			return converter.TileOptions{}, fmt.Errorf("invalid tile size %q (each dimension must be 1-%d)", spec, converter.MaxTileSize)
+		}
+		sizes[i] = n
 	}
+	if len(sizes) == 1 {
+		return converter.TileOptions{MaxWidth: sizes[0], MaxHeight: sizes[0], MaxLength: sizes[0]}, nil
+	}
+	return converter.TileOptions{MaxWidth: sizes[0], MaxHeight: sizes[1], MaxLength: sizes[2]}, nil
+}
 
-	fmt.Printf("Parsed %d chunks (data version: %d)\n", len(world.Chunks), world.WorldVersion)
+// convertTiled writes one schematic per tile as <base>_<x>_<y>_<z>.<ext> and
+// a <base>.manifest.json describing where each tile belongs in the world.
+func convertTiled(world *slime.SlimeWorld, opts *convertOptions, outputFile, reportFile string, log io.Writer) error {
+	format := opts.format
+	base := strings.TrimSuffix(outputFile, filepath.Ext(outputFile))
+	var tiles []converter.ManifestTile
 
-	result, err := converter.Convert(world)
+	unmappedCounts := make(map[string]int)
+
+	result, err := converter.ConvertTiled(world, format.limitTile(opts.tile), func(tile *converter.Tile) error {
+		name := fmt.Sprintf("%s_%d_%d_%d%s", base, tile.Index[0], tile.Index[1], tile.Index[2], format.ext)
+		schemData, unmapped, err := format.save(tile.Schematic, opts.saveOptions(name))
+		if err != nil {
+			return fmt.Errorf("saving schematic: %w", err)
+		}
+		for _, u := range unmapped {
+			unmappedCounts[u.State] += u.Count
+		}
+		if err := os.WriteFile(name, schemData, 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", name, err)
+		}
+		fmt.Fprintf(log, "  tile %d,%d,%d: %d blocks -> %s\n", tile.Index[0], tile.Index[1], tile.Index[2], tile.TotalBlocks, name)
+		tiles = append(tiles, converter.NewManifestTile(filepath.Base(name), tile))
+		return nil
+	})
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error converting: %v\n", err)
-		os.Exit(1)
+		return err
 	}
 
-	fmt.Printf("Converted %d non-air blocks (%d unique block states)\n",
-		result.TotalBlocks, len(result.Schematic.Palette))
-
-	schemData, err := result.Schematic.Save()
+	manifest := converter.Manifest{
+		DataVersion: int32(world.WorldVersion),
+		Origin:      result.Origin,
+		Size:        result.Size,
+		TileSize:    result.TileSize,
+		Grid:        result.Grid,
+		Tiles:       tiles,
+	}
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error saving schematic: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("encoding manifest: %w", err)
+	}
+	manifestFile := base + ".manifest.json"
+	if err := os.WriteFile(manifestFile, append(manifestData, '\n'), 0644); err != nil {
+		return fmt.Errorf("writing manifest: %w", err)
 	}
 
-	if err := os.WriteFile(*outputFile, schemData, 0644); err != nil {
-		fmt.Fprintf(os.Stderr, "Error writing output file: %v\n", err)
-		os.Exit(1)
+	printBounds(log, result.Bounds)
+	fmt.Fprintf(log, "Tiled %d x %d x %d volume into %d x %d x %d tiles\n",
+		result.Size[0], result.Size[1], result.Size[2], result.Grid[0], result.Grid[1], result.Grid[2])
+	fmt.Fprintf(log, "Converted %d non-air blocks into %d tiles\n", result.TotalBlocks, result.Tiles)
+	if n := len(result.Report.Dropped); n > 0 {
+		fmt.Fprintf(log, "Dropped %d entities/block entities (use -report for details)\n", n)
 	}
+	unmapped := schematic.UnmappedStates(unmappedCounts)
+	printUnmapped(log, unmapped, opts.formatName)
+	if reportFile != "" {
+		report := exportReport{Report: result.Report, Unmapped: unmapped}
+		if err := writeReport(reportFile, &report); err != nil {
+			return fmt.Errorf("writing report: %w", err)
+		}
+	}
+	fmt.Fprintf(log, "Manifest saved to: %s\n", manifestFile)
+	return nil
+}
+
+// printBounds prints the range of chunks and sections a conversion covered.
+func printBounds(log io.Writer, b converter.Bounds) {
+	fmt.Fprintf(log, "World bounds: chunks X=[%d, %d] Z=[%d, %d] sections Y=[%d, %d]\n",
+		b.MinChunkX, b.MaxChunkX, b.MinChunkZ, b.MaxChunkZ, b.MinSectionY, b.MaxSectionY)
+}
 
-	fmt.Printf("Schematic saved to: %s\n", *outputFile)
-	fmt.Printf("Dimensions: %d x %d x %d (Width x Height x Length)\n",
-		result.Schematic.Width, result.Schematic.Height, result.Schematic.Length)
-	fmt.Println("\nYou can load this schematic in Minecraft using WorldEdit:")
-	fmt.Println("  //schematic load <filename>")
-	fmt.Println("  //paste")
+// schematicName derives a display name for formats that store one
+// (e.g. Litematica) from the output file name.
+func schematicName(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
 }
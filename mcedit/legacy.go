@@ -0,0 +1,41 @@
+package mcedit
+
+import (
+	_ "embed"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/emmanuelvlad/slime2schem/internal/blockmap"
+)
+
+//go:embed legacy_blocks.txt
+var legacyBlocksTxt string
+
+// LegacyBlock is a pre-flattening numeric block id and data value.
+type LegacyBlock struct {
+	Id   uint16
+	Meta uint8
+}
+
+var legacyTable = sync.OnceValue(func() *blockmap.Table[LegacyBlock] {
+	return blockmap.MustParse("legacy_blocks.txt", legacyBlocksTxt, parseLegacyBlock)
+})
+
+func parseLegacyBlock(s string) (LegacyBlock, error) {
+	idStr, metaStr, _ := strings.Cut(s, ":")
+	id, err1 := strconv.ParseUint(idStr, 10, 12)
+	meta, err2 := strconv.ParseUint(metaStr, 10, 4)
+	if err1 != nil || err2 != nil {
+		return LegacyBlock{}, fmt.Errorf("invalid id:meta %q", s)
+	}
+	return LegacyBlock{Id: uint16(id), Meta: uint8(meta)}, nil
+}
+
+// LegacyBlockFor returns the legacy id:meta for a modern block state string
+// (as built by schematic.BlockStateString). ok is false when the state has
+// no equivalent in the embedded table.
+func LegacyBlockFor(state string) (block LegacyBlock, ok bool) {
+	return legacyTable().Lookup(state)
+}
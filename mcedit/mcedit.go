@@ -0,0 +1,118 @@
+// Package mcedit exports converted builds as legacy MCEdit/WorldEdit
+// .schematic files ("Alpha" materials) for servers running Minecraft 1.12
+// or older.
+//
+// Block states are translated back to numeric id:meta pairs using an
+// embedded table (legacy_blocks.txt). States without a legacy equivalent are
+// written as air and listed in the returned Unmapped slice. Block entity and
+// entity NBT is copied as is; the game's own data fixers do not run
+// backwards, so item stacks and other post-1.12 NBT may not load fully.
+package mcedit
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+
+	"github.com/emmanuelvlad/slime2schem/internal/nbtenc"
+	"github.com/emmanuelvlad/slime2schem/schematic"
+)
+
+// Save encodes the schematic as a gzipped .schematic file. It also returns
+// the block states that could not be represented, sorted by descending
+// count.
+func Save(s *schematic.Schematic) ([]byte, []schematic.UnmappedState, error) {
+	palette := s.PaletteNames()
+	legacy := make([]LegacyBlock, len(palette))
+	mapped := make([]bool, len(palette))
+	for i, state := range palette {
+		legacy[i], mapped[i] = LegacyBlockFor(state)
+	}
+
+	total := s.Width * s.Height * s.Length
+	blocks := make([]byte, total)
+	data := make([]byte, total)
+	var addBlocks []byte
+	unmappedCounts := make(map[string]int)
+
+	// Legacy index order (y, z, x) matches the schematic's own layout.
+	i := 0
+	for y := 0; y < s.Height; y++ {
+		for z := 0; z < s.Length; z++ {
+			for x := 0; x < s.Width; x++ {
+				idx := s.PaletteIndexAt(x, y, z)
+				if !mapped[idx] {
+					unmappedCounts[palette[idx]]++
+					i++
+					continue
+				}
+				b := legacy[idx]
+				blocks[i] = byte(b.Id)
+				data[i] = b.Meta
+				if b.Id > 0xFF {
+					if addBlocks == nil {
+						addBlocks = make([]byte, (total+1)/2)
+					}
+					// Two 4-bit values per byte; even indices use the low nibble.
+					if i&1 == 0 {
+						addBlocks[i>>1] |= byte(b.Id>>8) & 0x0F
+					} else {
+						addBlocks[i>>1] |= (byte(b.Id>>8) & 0x0F) << 4
+					}
+				}
+				i++
+			}
+		}
+	}
+
+	var gzBuf bytes.Buffer
+	gzWriter := gzip.NewWriter(&gzBuf)
+	w := nbtenc.NewWriter(gzWriter)
+
+	w.BeginCompound("Schematic")
+	w.WriteShort("Width", int16(s.Width))
+	w.WriteShort("Height", int16(s.Height))
+	w.WriteShort("Length", int16(s.Length))
+	w.WriteString("Materials", "Alpha")
+	w.WriteByteArray("Blocks", blocks)
+	w.WriteByteArray("Data", data)
+	if addBlocks != nil {
+		w.WriteByteArray("AddBlocks", addBlocks)
+	}
+
+	// WorldEdit pastes relative to WEOffset, the same offset v2/v3 use.
+	w.WriteInt("WEOriginX", 0)
+	w.WriteInt("WEOriginY", 0)
+	w.WriteInt("WEOriginZ", 0)
+	w.WriteInt("WEOffsetX", s.Offset[0])
+	w.WriteInt("WEOffsetY", s.Offset[1])
+	w.WriteInt("WEOffsetZ", s.Offset[2])
+
+	w.BeginList("TileEntities", nbtenc.TagCompound, len(s.BlockEntities))
+	for _, be := range s.BlockEntities {
+		w.WriteInt("x", be.Pos[0])
+		w.WriteInt("y", be.Pos[1])
+		w.WriteInt("z", be.Pos[2])
+		w.WriteString("id", be.Id)
+		w.WriteFields(be.Data, "x", "y", "z", "id")
+		w.EndCompound()
+	}
+
+	w.BeginList("Entities", nbtenc.TagCompound, len(s.Entities))
+	for _, e := range s.Entities {
+		w.WriteTag("Pos", []interface{}{e.Pos[0], e.Pos[1], e.Pos[2]})
+		w.WriteString("id", e.Id)
+		w.WriteFields(e.Data, "Pos", "id")
+		w.EndCompound()
+	}
+
+	w.EndCompound() // Schematic
+
+	if w.Err() != nil {
+		return nil, nil, fmt.Errorf("encoding schematic NBT: %w", w.Err())
+	}
+	if err := gzWriter.Close(); err != nil {
+		return nil, nil, fmt.Errorf("closing gzip writer: %w", err)
+	}
+	return gzBuf.Bytes(), schematic.UnmappedStates(unmappedCounts), nil
+}
@@ -0,0 +1,70 @@
+package mcedit
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/Tnze/go-mc/nbt"
+
+	"github.com/emmanuelvlad/slime2schem/schematic"
+)
+
+func TestLegacyBlockFor(t *testing.T) {
+	tests := []struct {
+		state string
+		want  LegacyBlock
+		ok    bool
+	}{
+		{"minecraft:stone", LegacyBlock{1, 0}, true},
+		{"minecraft:granite", LegacyBlock{1, 1}, true},
+		{"minecraft:red_wool", LegacyBlock{35, 14}, true},
+		{"minecraft:oak_log[axis=x]", LegacyBlock{17, 4}, true},
+		{"minecraft:oak_stairs[facing=west,half=top,shape=straight,waterlogged=false]", LegacyBlock{53, 5}, true},
+		{"minecraft:oak_stairs[facing=west,half=bottom,shape=outer_left,waterlogged=false]", LegacyBlock{53, 1}, true},
+		{"minecraft:stone_brick_slab[type=top,waterlogged=false]", LegacyBlock{44, 13}, true},
+		{"minecraft:stone_brick_slab[type=double,waterlogged=false]", LegacyBlock{43, 5}, true},
+		{"minecraft:cave_air", LegacyBlock{0, 0}, true},
+		{"minecraft:sculk", LegacyBlock{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := LegacyBlockFor(tt.state)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("LegacyBlockFor(%s) = %d:%d, %v; want %d:%d, %v", tt.state, got.Id, got.Meta, ok, tt.want.Id, tt.want.Meta, tt.ok)
+		}
+	}
+}
+
+func TestSave(t *testing.T) {
+	s := schematic.NewSchematic(3, 1, 1, 3953)
+	s.SetBlock(0, 0, 0, "minecraft:oak_log[axis=z]")
+	s.SetBlock(1, 0, 0, "minecraft:sculk")
+	s.SetBlock(2, 0, 0, "minecraft:sculk")
+
+	data, unmapped, err := Save(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unmapped) != 1 || unmapped[0].State != "minecraft:sculk" || unmapped[0].Count != 2 {
+		t.Errorf("unmapped = %+v, want 2 sculk", unmapped)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file struct {
+		Width, Height, Length int16
+		Materials             string
+		Blocks, Data          []byte
+	}
+	if _, err := nbt.NewDecoder(gz).Decode(&file); err != nil {
+		t.Fatal(err)
+	}
+	if file.Width != 3 || file.Height != 1 || file.Length != 1 || file.Materials != "Alpha" {
+		t.Errorf("header = %+v", file)
+	}
+	if !bytes.Equal(file.Blocks, []byte{17, 0, 0}) || !bytes.Equal(file.Data, []byte{8, 0, 0}) {
+		t.Errorf("blocks %v, data %v; want [17 0 0], [8 0 0]", file.Blocks, file.Data)
+	}
+}
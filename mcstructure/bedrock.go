@@ -0,0 +1,76 @@
+package mcstructure
+
+import (
+	_ "embed"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/emmanuelvlad/slime2schem/internal/blockmap"
+)
+
+//go:embed bedrock_blocks.txt
+var bedrockBlocksTxt string
+
+// BlockVersion is the Bedrock block version the embedded table's names and
+// states belong to (1.18.10). The game upgrades older states on load.
+const BlockVersion = 17959425
+
+// BedrockBlock is a Bedrock Edition block name and its states. State values
+// are int8 (booleans), int32 or string, matching their NBT tag types.
+type BedrockBlock struct {
+	Name   string
+	States map[string]interface{}
+}
+
+var bedrockTable = sync.OnceValue(func() *blockmap.Table[BedrockBlock] {
+	return blockmap.MustParse("bedrock_blocks.txt", bedrockBlocksTxt, parseBedrockBlock)
+})
+
+func parseBedrockBlock(s string) (BedrockBlock, error) {
+	name, rest, ok := strings.Cut(s, "[")
+	block := BedrockBlock{Name: name, States: map[string]interface{}{}}
+	if !ok {
+		return block, nil
+	}
+	rest, ok = strings.CutSuffix(rest, "]")
+	if !ok {
+		return BedrockBlock{}, fmt.Errorf("invalid Bedrock block %q", s)
+	}
+	for _, pair := range strings.Split(rest, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			return BedrockBlock{}, fmt.Errorf("invalid Bedrock state %q in %q", pair, s)
+		}
+		switch {
+		case v == "true":
+			block.States[k] = int8(1)
+		case v == "false":
+			block.States[k] = int8(0)
+		default:
+			if n, err := strconv.ParseInt(v, 10, 32); err == nil {
+				block.States[k] = int32(n)
+			} else {
+				block.States[k] = v
+			}
+		}
+	}
+	return block, nil
+}
+
+// BedrockBlockFor returns the Bedrock block for a Java block state string
+// (as built by schematic.BlockStateString). ok is false when the state has
+// no equivalent in the embedded table.
+func BedrockBlockFor(state string) (block BedrockBlock, ok bool) {
+	return bedrockTable().Lookup(state)
+}
+
+// key identifies a Bedrock block for palette deduplication.
+func (b BedrockBlock) key() string {
+	states := make(map[string]string, len(b.States))
+	for k, v := range b.States {
+		states[k] = fmt.Sprint(v)
+	}
+	return b.Name + fmt.Sprint(states) // fmt prints maps with sorted keys
+}
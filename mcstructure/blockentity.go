@@ -0,0 +1,213 @@
+package mcstructure
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/emmanuelvlad/slime2schem/schematic"
+)
+
+// bedrockBlockEntityIds maps the Java block entity ids that are translated
+// to their Bedrock ids. Other block entities are left for the game to
+// create with default contents.
+var bedrockBlockEntityIds = map[string]string{
+	"minecraft:chest":         "Chest",
+	"minecraft:trapped_chest": "Chest",
+	"minecraft:ender_chest":   "EnderChest",
+	"minecraft:barrel":        "Barrel",
+	"minecraft:furnace":       "Furnace",
+	"minecraft:hopper":        "Hopper",
+	"minecraft:dispenser":     "Dispenser",
+	"minecraft:dropper":       "Dropper",
+	"minecraft:shulker_box":   "ShulkerBox",
+	"minecraft:sign":          "Sign",
+	"minecraft:hanging_sign":  "HangingSign",
+}
+
+// signTextColors are the ARGB colors Bedrock uses for sign text dyes.
+var signTextColors = map[string]uint32{
+	"white":      0xFFF0F0F0,
+	"orange":     0xFFF9801D,
+	"magenta":    0xFFC74EBD,
+	"light_blue": 0xFF3AB3DA,
+	"yellow":     0xFFFED83D,
+	"lime":       0xFF80C71F,
+	"pink":       0xFFF38BAA,
+	"gray":       0xFF474F52,
+	"light_gray": 0xFF9D9D97,
+	"cyan":       0xFF169C9C,
+	"purple":     0xFF8932B8,
+	"blue":       0xFF3C44AA,
+	"brown":      0xFF835432,
+	"green":      0xFF5E7C16,
+	"red":        0xFFB02E26,
+	"black":      0xFF000000,
+}
+
+// translateBlockEntity converts a Java block entity to Bedrock block entity
+// data. ok is false for block entities that are not translated.
+func translateBlockEntity(be schematic.BlockEntity) (data map[string]interface{}, ok bool) {
+	id := be.Id
+	switch {
+	case strings.HasSuffix(id, "hanging_sign"):
+		id = "minecraft:hanging_sign"
+	case strings.HasSuffix(id, "_sign"):
+		id = "minecraft:sign"
+	}
+	if strings.HasSuffix(id, "_shulker_box") {
+		id = "minecraft:shulker_box"
+	}
+	bedrockId, ok := bedrockBlockEntityIds[id]
+	if !ok {
+		return nil, false
+	}
+
+	data = map[string]interface{}{
+		"id":        bedrockId,
+		"x":         be.Pos[0],
+		"y":         be.Pos[1],
+		"z":         be.Pos[2],
+		"isMovable": int8(1),
+	}
+	if name, ok := be.Data["CustomName"]; ok {
+		data["CustomName"] = plainText(name)
+	}
+	if bedrockId == "Sign" || bedrockId == "HangingSign" {
+		translateSign(be.Data, data)
+		return data, true
+	}
+	if items, ok := be.Data["Items"].([]interface{}); ok {
+		data["Items"] = translateItems(items)
+	}
+	return data, true
+}
+
+// translateItems converts Java item stacks (pre- and post-1.20.5) to
+// Bedrock's Name/Count/Damage layout. Item ids are kept as is; most common
+// items share their id across editions.
+func translateItems(items []interface{}) []interface{} {
+	out := make([]interface{}, 0, len(items))
+	for _, it := range items {
+		item, ok := it.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		id, _ := item["id"].(string)
+		if id == "" {
+			continue
+		}
+		count := int8(1)
+		if c, ok := toInt(item["count"]); ok {
+			count = int8(c)
+		} else if c, ok := toInt(item["Count"]); ok {
+			count = int8(c)
+		}
+		slot, _ := toInt(item["Slot"])
+		out = append(out, map[string]interface{}{
+			"Name":        id,
+			"Count":       count,
+			"Damage":      int16(0),
+			"Slot":        int8(slot),
+			"WasPickedUp": int8(0),
+		})
+	}
+	return out
+}
+
+// translateSign converts Java sign text (front_text/back_text since 1.20,
+// Text1-Text4 before) into Bedrock's FrontText/BackText.
+func translateSign(java, bedrock map[string]interface{}) {
+	if front, ok := java["front_text"].(map[string]interface{}); ok {
+		bedrock["FrontText"] = signSide(front["messages"], front["color"], front["has_glowing_text"])
+		back, _ := java["back_text"].(map[string]interface{})
+		bedrock["BackText"] = signSide(back["messages"], back["color"], back["has_glowing_text"])
+	} else {
+		lines := []interface{}{java["Text1"], java["Text2"], java["Text3"], java["Text4"]}
+		bedrock["FrontText"] = signSide(lines, java["Color"], java["GlowingText"])
+		bedrock["BackText"] = signSide(nil, nil, nil)
+	}
+	waxed, _ := toInt(java["is_waxed"])
+	bedrock["IsWaxed"] = int8(waxed)
+}
+
+func signSide(messages, color, glowing interface{}) map[string]interface{} {
+	var lines []string
+	if list, ok := messages.([]interface{}); ok {
+		for _, m := range list {
+			lines = append(lines, plainText(m))
+		}
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	name, _ := color.(string)
+	argb, ok := signTextColors[name]
+	if !ok {
+		argb = signTextColors["black"]
+	}
+	glow, _ := toInt(glowing)
+	return map[string]interface{}{
+		"Text":              strings.Join(lines, "\n"),
+		"TextOwner":         "",
+		"SignTextColor":     int32(argb),
+		"IgnoreLighting":    int8(glow),
+		"HideGlowOutline":   int8(0),
+		"PersistFormatting": int8(1),
+	}
+}
+
+// plainText flattens a Java text component to plain text. Components may be
+// JSON strings (before 1.21.5), NBT compounds, or plain strings.
+func plainText(v interface{}) string {
+	switch c := v.(type) {
+	case string:
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(c), &decoded); err != nil {
+			return c
+		}
+		switch d := decoded.(type) {
+		case string:
+			return d
+		case map[string]interface{}, []interface{}:
+			return plainText(d)
+		default:
+			return c
+		}
+	case map[string]interface{}:
+		var b strings.Builder
+		if text, ok := c["text"].(string); ok {
+			b.WriteString(text)
+		}
+		if extra, ok := c["extra"].([]interface{}); ok {
+			for _, e := range extra {
+				b.WriteString(plainText(e))
+			}
+		}
+		return b.String()
+	case []interface{}:
+		var b strings.Builder
+		for _, e := range c {
+			b.WriteString(plainText(e))
+		}
+		return b.String()
+	default:
+		return ""
+	}
+}
+
+func toInt(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case int8:
+		return int(n), true
+	case int16:
+		return int(n), true
+	case int32:
+		return int(n), true
+	case int64:
+		return int(n), true
+	case float64: // decoded from JSON
+		return int(n), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,29 @@
+package mcstructure
+
+import (
+	"testing"
+
+	"github.com/emmanuelvlad/slime2schem/schematic"
+)
+
+func TestTranslateSignIds(t *testing.T) {
+	for id, want := range map[string]string{
+		"minecraft:sign":                  "Sign",
+		"minecraft:oak_sign":              "Sign",
+		"minecraft:hanging_sign":          "HangingSign",
+		"minecraft:oak_hanging_sign":      "HangingSign",
+		"minecraft:oak_wall_hanging_sign": "HangingSign",
+	} {
+		data, ok := translateBlockEntity(schematic.BlockEntity{Id: id, Data: map[string]interface{}{}})
+		if !ok {
+			t.Errorf("%s: not translated", id)
+			continue
+		}
+		if got := data["id"]; got != want {
+			t.Errorf("%s: id = %v, want %s", id, got, want)
+		}
+		if _, ok := data["FrontText"]; !ok {
+			t.Errorf("%s: no FrontText", id)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+// Package mcstructure exports converted builds as Bedrock Edition structure
+// (.mcstructure) files, loadable with structure blocks or /structure load.
+//
+// Java block states are translated to Bedrock names and states using an
+// embedded table (bedrock_blocks.txt); states without an entry are written
+// as air and listed in the returned Unmapped slice. Waterlogged blocks get
+// water in the second block layer. Block entity data is translated for
+// containers (items keep their Java ids) and signs; other block entities are
+// recreated empty by the game. Entities are not exported, as Bedrock entity
+// data has little in common with Java's.
+package mcstructure
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"github.com/emmanuelvlad/slime2schem/internal/nbtenc"
+	"github.com/emmanuelvlad/slime2schem/schematic"
+)
+
+// FormatVersion is the .mcstructure format version written by Save.
+const FormatVersion = 1
+
+var (
+	airBlock   = BedrockBlock{Name: "minecraft:air", States: map[string]interface{}{}}
+	waterBlock = BedrockBlock{Name: "minecraft:water", States: map[string]interface{}{"liquid_depth": int32(0)}}
+)
+
+// Save encodes the schematic as an uncompressed little-endian .mcstructure
+// file. It also returns the block states that could not be represented,
+// sorted by descending count.
+func Save(s *schematic.Schematic) ([]byte, []schematic.UnmappedState, error) {
+	var palette []BedrockBlock
+	paletteIndex := make(map[string]int32)
+	add := func(b BedrockBlock) int32 {
+		k := b.key()
+		if idx, ok := paletteIndex[k]; ok {
+			return idx
+		}
+		idx := int32(len(palette))
+		palette = append(palette, b)
+		paletteIndex[k] = idx
+		return idx
+	}
+	air := add(airBlock)
+
+	// Translate each Java palette entry once.
+	javaPalette := s.PaletteNames()
+	layer0 := make([]int32, len(javaPalette))
+	layer1 := make([]int32, len(javaPalette))
+	mapped := make([]bool, len(javaPalette))
+	for i, state := range javaPalette {
+		layer1[i] = -1
+		b, ok := BedrockBlockFor(state)
+		if !ok {
+			layer0[i] = air
+			continue
+		}
+		mapped[i] = true
+		layer0[i] = add(b)
+		if _, props := schematic.ParseBlockState(state); props["waterlogged"] == "true" {
+			layer1[i] = add(waterBlock)
+		}
+	}
+
+	// Bedrock orders blocks by x, then y, then z (z varies fastest).
+	total := s.Width * s.Height * s.Length
+	blocks := make([]int32, total)
+	liquids := make([]int32, total)
+	unmappedCounts := make(map[string]int)
+	i := 0
+	for x := 0; x < s.Width; x++ {
+		for y := 0; y < s.Height; y++ {
+			for z := 0; z < s.Length; z++ {
+				idx := s.PaletteIndexAt(x, y, z)
+				if !mapped[idx] {
+					unmappedCounts[javaPalette[idx]]++
+				}
+				blocks[i] = layer0[idx]
+				liquids[i] = layer1[idx]
+				i++
+			}
+		}
+	}
+
+	positionData := make(map[string]interface{})
+	for _, be := range s.BlockEntities {
+		data, ok := translateBlockEntity(be)
+		if !ok {
+			continue
+		}
+		x, y, z := int(be.Pos[0]), int(be.Pos[1]), int(be.Pos[2])
+		index := (x*s.Height+y)*s.Length + z
+		positionData[strconv.Itoa(index)] = map[string]interface{}{
+			"block_entity_data": data,
+		}
+	}
+
+	var buf bytes.Buffer
+	w := nbtenc.NewLittleEndianWriter(&buf)
+
+	w.BeginCompound("")
+	w.WriteInt("format_version", FormatVersion)
+	w.WriteTag("size", []interface{}{int32(s.Width), int32(s.Height), int32(s.Length)})
+	w.WriteTag("structure_world_origin", []interface{}{int32(0), int32(0), int32(0)})
+
+	w.BeginCompound("structure")
+	w.BeginList("block_indices", nbtenc.TagList, 2)
+	writeIntListPayload(w, blocks)
+	writeIntListPayload(w, liquids)
+	w.BeginList("entities", nbtenc.TagCompound, 0)
+
+	w.BeginCompound("palette")
+	w.BeginCompound("default")
+	w.BeginList("block_palette", nbtenc.TagCompound, len(palette))
+	for _, b := range palette {
+		w.WriteString("name", b.Name)
+		w.WriteTag("states", b.States)
+		w.WriteInt("version", BlockVersion)
+		w.EndCompound()
+	}
+	w.WriteTag("block_position_data", positionData)
+	w.EndCompound() // default
+	w.EndCompound() // palette
+
+	w.EndCompound() // structure
+	w.EndCompound() // root
+
+	if w.Err() != nil {
+		return nil, nil, fmt.Errorf("encoding mcstructure NBT: %w", w.Err())
+	}
+	return buf.Bytes(), schematic.UnmappedStates(unmappedCounts), nil
+}
+
+// writeIntListPayload writes the payload of a List of Int tags, for use as
+// an element of an enclosing list.
+func writeIntListPayload(w *nbtenc.Writer, values []int32) {
+	w.Write([]byte{nbtenc.TagInt})
+	w.WriteNumber(int32(len(values)))
+	w.WriteNumber(values)
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/emmanuelvlad/slime2schem/converter"
+	"github.com/emmanuelvlad/slime2schem/gltf"
+	"github.com/emmanuelvlad/slime2schem/litematica"
+	"github.com/emmanuelvlad/slime2schem/mcedit"
+	"github.com/emmanuelvlad/slime2schem/mcstructure"
+	"github.com/emmanuelvlad/slime2schem/schematic"
+	"github.com/emmanuelvlad/slime2schem/structure"
+	"github.com/emmanuelvlad/slime2schem/vox"
+)
+
+// outputFormat is a single-file format the converter can write.
+type outputFormat struct {
+	ext string
+	// save encodes s; it also returns the block states the format could not
+	// represent, if any.
+	save func(s *schematic.Schematic, opts saveOptions) ([]byte, []schematic.UnmappedState, error)
+	// maxSize, if set, is the largest volume one file can hold along each
+	// axis; bigger worlds are split into tiles.
+	maxSize int
+}
+
+// saveOptions holds what a format needs beyond the schematic: the metadata
+// stored by formats that have room for it (e.g. Litematica), and the
+// format-specific flags.
+type saveOptions struct {
+	name   string
+	author string
+	glb    gltf.Options
+}
+
+var outputFormats = map[string]outputFormat{
+	"v3": {".schem", func(s *schematic.Schematic, _ saveOptions) ([]byte, []schematic.UnmappedState, error) {
+		data, err := s.SaveFormat(schematic.FormatV3)
+		return data, nil, err
+	}, 0},
+	"v2": {".schem", func(s *schematic.Schematic, _ saveOptions) ([]byte, []schematic.UnmappedState, error) {
+		data, err := s.SaveFormat(schematic.FormatV2)
+		return data, nil, err
+	}, 0},
+	"litematic": {".litematic", func(s *schematic.Schematic, opts saveOptions) ([]byte, []schematic.UnmappedState, error) {
+		data, err := litematica.Save(s, litematica.Options{Name: opts.name, Author: opts.author})
+		return data, nil, err
+	}, 0},
+	"structure": {".nbt", func(s *schematic.Schematic, _ saveOptions) ([]byte, []schematic.UnmappedState, error) {
+		data, err := structure.Save(s)
+		return data, nil, err
+	}, structure.MaxSize},
+	"mcedit": {".schematic", func(s *schematic.Schematic, _ saveOptions) ([]byte, []schematic.UnmappedState, error) {
+		return mcedit.Save(s)
+	}, 0},
+	"mcstructure": {".mcstructure", func(s *schematic.Schematic, _ saveOptions) ([]byte, []schematic.UnmappedState, error) {
+		return mcstructure.Save(s)
+	}, 0},
+	"glb": {".glb", func(s *schematic.Schematic, opts saveOptions) ([]byte, []schematic.UnmappedState, error) {
+		data, err := gltf.Save(s, opts.glb)
+		return data, nil, err
+	}, 0},
+	"vox": {".vox", func(s *schematic.Schematic, _ saveOptions) ([]byte, []schematic.UnmappedState, error) {
+		data, err := vox.Save(s)
+		return data, nil, err
+	}, 0},
+}
+
+// parseOutputFormat looks up an output format by name.
+func parseOutputFormat(name string) (outputFormat, error) {
+	if f, ok := outputFormats[name]; ok {
+		return f, nil
+	}
+	return outputFormat{}, fmt.Errorf("unknown output format %q (expected %s)", name, strings.Join(outputFormatNames(), ", "))
+}
+
+func outputFormatNames() []string {
+	names := make([]string, 0, len(outputFormats))
+	for name := range outputFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// exportReport is the JSON written by -report.
+type exportReport struct {
+	converter.Report
+	// Unmapped lists block states the output format cannot represent; they
+	// were written as air.
+	Unmapped []schematic.UnmappedState `json:"unmapped,omitempty"`
+}
+
+// printUnmapped summarises block states that were lost in the export.
+func printUnmapped(w io.Writer, unmapped []schematic.UnmappedState, formatName string) {
+	if len(unmapped) == 0 {
+		return
+	}
+	blocks := 0
+	for _, u := range unmapped {
+		blocks += u.Count
+	}
+	fmt.Fprintf(w, "%d block states (%d blocks) have no %s equivalent and were written as air (use -report for details)\n",
+		len(unmapped), blocks, formatName)
+}
+
+// limitTile shrinks opts so no tile exceeds the format's size limit.
+func (f outputFormat) limitTile(opts converter.TileOptions) converter.TileOptions {
+	if f.maxSize == 0 {
+		return opts
+	}
+	limit := func(n int) int {
+		if n <= 0 || n > f.maxSize {
+			return f.maxSize
+		}
+		return n
+	}
+	return converter.TileOptions{
+		MaxWidth:  limit(opts.MaxWidth),
+		MaxHeight: limit(opts.MaxHeight),
+		MaxLength: limit(opts.MaxLength),
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/emmanuelvlad/slime2schem/converter"
+	"github.com/emmanuelvlad/slime2schem/internal/testworld"
+	"github.com/emmanuelvlad/slime2schem/slime"
+)
+
+// floorWorld returns a row of chunks along X, each sections high, with a
+// block in every section so none of the volume is empty.
+func floorWorld(chunks, sections int) *slime.SlimeWorld {
+	column := make([]slime.Section, sections)
+	for i := range column {
+		column[i] = testworld.Floor("minecraft:stone")
+	}
+	w := testworld.New(0)
+	for x := 0; x < chunks; x++ {
+		w.Chunks = append(w.Chunks, slime.Chunk{X: int32(x), Sections: column})
+	}
+	return w
+}
+
+func TestStructureSplitsAboveMaxSize(t *testing.T) {
+	opts := convertOptions{formatName: "structure"}
+	if err := opts.parse(); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		chunks, sections int
+		wantSizes        [][3]int // nil if written as one file
+	}{
+		{3, 3, nil}, // exactly 48x48x16
+		{4, 1, [][3]int{{48, 16, 16}, {16, 16, 16}}},
+		{1, 4, [][3]int{{16, 48, 16}, {16, 16, 16}}},
+	}
+	for _, tt := range tests {
+		world := floorWorld(tt.chunks, tt.sections)
+		if got := opts.tiled(world); got != (tt.wantSizes != nil) {
+			t.Errorf("%d chunks, %d sections: tiled = %v", tt.chunks, tt.sections, got)
+			continue
+		}
+		if tt.wantSizes == nil {
+			continue
+		}
+		var sizes [][3]int
+		_, err := converter.ConvertTiled(world, opts.format.limitTile(opts.tile), func(tile *converter.Tile) error {
+			sizes = append(sizes, [3]int{tile.Schematic.Width, tile.Schematic.Height, tile.Schematic.Length})
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(sizes, tt.wantSizes) {
+			t.Errorf("%d chunks, %d sections: tiles %v, want %v", tt.chunks, tt.sections, sizes, tt.wantSizes)
+		}
+	}
+
+	// Formats without a size limit are only tiled on request.
+	v3 := convertOptions{formatName: "v3"}
+	if err := v3.parse(); err != nil {
+		t.Fatal(err)
+	}
+	if v3.tiled(floorWorld(4, 4)) {
+		t.Error("v3 output tiled without -tile")
+	}
+}
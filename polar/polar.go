@@ -0,0 +1,124 @@
+// Package polar reads and writes worlds in the Polar format used by
+// Minestom servers (https://github.com/hollow-cube/polar).
+//
+// Worlds are loaded into the same slime.SlimeWorld model the slime reader
+// produces. Polar has no entities or scheduled ticks, so those are dropped
+// when writing, and slime has no place for Polar's user data, so it is
+// carried in the world's and chunks' Extra compounds under UserDataKey.
+package polar
+
+import (
+	"github.com/emmanuelvlad/slime2schem/slime"
+)
+
+// Magic is the first four bytes of a Polar file ("Polr").
+const Magic = 0x506F6C72
+
+// Format versions, as numbered by the reference implementation.
+const (
+	versionUnifiedLight         = 1
+	versionUserDataOptBlockNBT  = 2
+	versionMinestomNBTReadBreak = 3
+	versionWorldUserData        = 4
+	versionShortGrass           = 5
+	versionDataConverter        = 6
+	versionImprovedLight        = 7
+
+	// Version is the format version written by WriteWorld.
+	Version = versionImprovedLight
+)
+
+// Compression types.
+const (
+	CompressionNone = 0
+	CompressionZstd = 1
+)
+
+// Light content types (version 7 and later).
+const (
+	lightMissing = 0
+	lightEmpty   = 1
+	lightFull    = 2
+	lightPresent = 3
+)
+
+// legacyDataVersion is assumed for files older than versionDataConverter,
+// which did not record one (Minecraft 1.20.4, current at the time).
+const legacyDataVersion = 3700
+
+// UserDataKey is the Extra key holding a world's or chunk's Polar user
+// data, as a byte array.
+const UserDataKey = "PolarUserData"
+
+// heightmapNames lists heightmap types by their bit in a chunk's heightmap
+// mask.
+var heightmapNames = []string{
+	"MOTION_BLOCKING",
+	"MOTION_BLOCKING_NO_LEAVES",
+	"OCEAN_FLOOR",
+	"OCEAN_FLOOR_WG",
+	"WORLD_SURFACE",
+	"WORLD_SURFACE_WG",
+}
+
+// Entry counts of a section's block and biome palettes.
+const (
+	blocksPerSection = 4096
+	biomesPerSection = 64
+)
+
+// bitsFor returns the packed entry size Polar uses for a palette: just
+// enough bits to index every entry, with no minimum.
+func bitsFor(paletteSize int) int {
+	bits := 0
+	for (1 << bits) < paletteSize {
+		bits++
+	}
+	return bits
+}
+
+// unpack reads n entries of the given size from longs in which entries do
+// not span two longs (the layout shared with vanilla chunks).
+func unpack(data []int64, bits, n int) []int {
+	out := make([]int, n)
+	if bits == 0 {
+		return out
+	}
+	perLong := 64 / bits
+	mask := uint64(1)<<bits - 1
+	for i := range out {
+		long := i / perLong
+		if long >= len(data) {
+			break
+		}
+		out[i] = int(uint64(data[long]) >> ((i % perLong) * bits) & mask)
+	}
+	return out
+}
+
+// pack is the inverse of unpack.
+func pack(values []int, bits int) []int64 {
+	perLong := 64 / bits
+	data := make([]int64, (len(values)+perLong-1)/perLong)
+	for i, v := range values {
+		data[i/perLong] |= int64(uint64(v) << ((i % perLong) * bits))
+	}
+	return data
+}
+
+// sectionIndices returns the palette index of every block in s, in
+// y, z, x order.
+func sectionIndices(s *slime.Section) []int {
+	out := make([]int, blocksPerSection)
+	if len(s.BlockPalette) <= 1 {
+		return out
+	}
+	for y := 0; y < 16; y++ {
+		for z := 0; z < 16; z++ {
+			for x := 0; x < 16; x++ {
+				out[y*256+z*16+x] = s.PaletteIndexAt(x, y, z)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,184 @@
+package polar
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/emmanuelvlad/slime2schem/internal/testworld"
+	"github.com/emmanuelvlad/slime2schem/slime"
+)
+
+func testWorld() *slime.SlimeWorld {
+	section := testworld.Section("minecraft:air", map[[3]int]string{
+		{0, 0, 0}: "minecraft:stone",
+		{1, 0, 0}: "minecraft:oak_stairs[facing=east,half=top]",
+	})
+	section.BlockLight = bytes.Repeat([]byte{0x77}, 2048)
+	chest := testworld.Chest(37, -63, -44)
+	chest["CustomName"] = "box"
+	return testworld.New(testworld.MinY,
+		slime.Chunk{X: 2, Z: -3, Sections: []slime.Section{section}, TileEntities: []map[string]interface{}{chest}},
+		slime.Chunk{X: 0, Z: 0},
+	)
+}
+
+func TestWriteReadRoundTrip(t *testing.T) {
+	for _, uncompressed := range []bool{false, true} {
+		want := testWorld()
+		data, err := WriteWorld(want, WriteOptions{MinY: DefaultMinY, Uncompressed: uncompressed})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ReadWorld(data)
+		if err != nil {
+			t.Fatalf("uncompressed=%v: %v", uncompressed, err)
+		}
+
+		if got.WorldVersion != want.WorldVersion {
+			t.Errorf("uncompressed=%v: data version = %d, want %d", uncompressed, got.WorldVersion, want.WorldVersion)
+		}
+		if len(got.Chunks) != 2 {
+			t.Fatalf("uncompressed=%v: %d chunks, want 2", uncompressed, len(got.Chunks))
+		}
+		c := got.Chunks[0]
+		if c.X != 2 || c.Z != -3 || len(c.Sections) != 1 {
+			t.Fatalf("uncompressed=%v: chunk %d,%d with %d sections, want 2,-3 with 1", uncompressed, c.X, c.Z, len(c.Sections))
+		}
+		s, ws := c.Sections[0], want.Chunks[0].Sections[0]
+		for _, pos := range [][3]int{{0, 0, 0}, {1, 0, 0}, {2, 0, 0}, {15, 15, 15}} {
+			g, w := s.GetBlockAt(pos[0], pos[1], pos[2]), ws.GetBlockAt(pos[0], pos[1], pos[2])
+			if g.Name != w.Name || len(g.Properties) != len(w.Properties) || g.Properties["facing"] != w.Properties["facing"] {
+				t.Errorf("uncompressed=%v: block at %v = %v, want %v", uncompressed, pos, g, w)
+			}
+		}
+		if !bytes.Equal(s.BlockLight, ws.BlockLight) {
+			t.Errorf("uncompressed=%v: block light differs", uncompressed)
+		}
+		if len(c.TileEntities) != 1 {
+			t.Fatalf("uncompressed=%v: %d block entities, want 1", uncompressed, len(c.TileEntities))
+		}
+		te := c.TileEntities[0]
+		if te["id"] != "minecraft:chest" || te["CustomName"] != "box" {
+			t.Errorf("uncompressed=%v: block entity = %v", uncompressed, te)
+		}
+		x, _ := te["x"].(int32)
+		y, _ := te["y"].(int32)
+		z, _ := te["z"].(int32)
+		if x != 37 || y != -63 || z != -44 {
+			t.Errorf("uncompressed=%v: block entity at %d,%d,%d, want 37,-63,-44", uncompressed, x, y, z)
+		}
+	}
+}
+
+func TestPackUnpack(t *testing.T) {
+	values := make([]int, 4096)
+	for i := range values {
+		values[i] = i % 37
+	}
+	if got := unpack(pack(values, 6), 6, len(values)); !slices.Equal(got, values) {
+		t.Error("unpack(pack(values)) differs from values")
+	}
+}
+
+func TestSectionPaletteWidths(t *testing.T) {
+	for _, size := range []int{1, 2, 3, 5, 16, 17, 33} {
+		blocks := make(map[[3]int]string)
+		for i := 1; i < size; i++ {
+			blocks[[3]int{i % 16, i / 16, 15}] = fmt.Sprintf("minecraft:wool_%02d", i)
+		}
+		want := testworld.Section("minecraft:air", blocks)
+
+		var w writer
+		if err := writeSection(&w, &want); err != nil {
+			t.Fatal(err)
+		}
+		data := w.Bytes()
+
+		// Polar packs indices with just enough bits, unlike slime's 4 or more.
+		r := &reader{r: bytes.NewReader(data)}
+		r.bool()
+		r.stringList()
+		if size > 1 {
+			bits := bitsFor(size)
+			perLong := 64 / bits
+			if n := len(r.longArray()); n != (4096+perLong-1)/perLong {
+				t.Errorf("palette of %d: %d longs, want %d bits per block", size, n, bits)
+			}
+		}
+
+		r = &reader{r: bytes.NewReader(data)}
+		got := readSection(r, Version)
+		if r.err != nil {
+			t.Fatalf("palette of %d: %v", size, r.err)
+		}
+		for x := 0; x < 16; x++ {
+			for y := 0; y < 3; y++ {
+				if g, w := got.GetBlockAt(x, y, 15), want.GetBlockAt(x, y, 15); g.Name != w.Name {
+					t.Errorf("palette of %d: block at %d,%d,15 = %s, want %s", size, x, y, g.Name, w.Name)
+				}
+			}
+		}
+	}
+}
+
+func TestReadSectionLightAndBiomes(t *testing.T) {
+	light := bytes.Repeat([]byte{0x5A}, 2048)
+	tests := []struct {
+		name      string
+		version   int16
+		biomes    []string
+		light     func(w *writer)
+		wantBlock []byte
+		wantSky   []byte
+	}{
+		{"missing and present", Version, []string{"minecraft:plains"}, func(w *writer) {
+			w.WriteByte(lightMissing)
+			w.WriteByte(lightPresent)
+			w.Write(light)
+		}, nil, light},
+		{"empty and full", Version, []string{"minecraft:plains", "minecraft:desert"}, func(w *writer) {
+			w.WriteByte(lightEmpty)
+			w.WriteByte(lightFull)
+		}, make([]byte, 2048), bytes.Repeat([]byte{0xFF}, 2048)},
+		{"separate flags", versionImprovedLight - 1, []string{"minecraft:plains"}, func(w *writer) {
+			w.bool(true)
+			w.Write(light)
+			w.bool(false)
+		}, light, nil},
+		{"one flag for both", versionUnifiedLight, nil, func(w *writer) {
+			w.bool(true)
+			w.Write(light)
+			w.Write(light)
+		}, light, light},
+	}
+	for _, tt := range tests {
+		var w writer
+		w.bool(false)
+		w.stringList([]string{"minecraft:stone"})
+		w.stringList(tt.biomes)
+		if len(tt.biomes) > 1 {
+			w.longArray(pack(make([]int, biomesPerSection), bitsFor(len(tt.biomes))))
+		}
+		tt.light(&w)
+		w.WriteByte(0x42) // the next section
+
+		r := &reader{r: bytes.NewReader(w.Bytes())}
+		s := readSection(r, tt.version)
+		if r.err != nil {
+			t.Fatalf("%s: %v", tt.name, r.err)
+		}
+		if !bytes.Equal(s.BlockLight, tt.wantBlock) || !bytes.Equal(s.SkyLight, tt.wantSky) {
+			t.Errorf("%s: block light %d bytes, sky light %d bytes", tt.name, len(s.BlockLight), len(s.SkyLight))
+		}
+		palette, _ := s.Biomes["palette"].([]interface{})
+		_, hasData := s.Biomes["data"]
+		if len(palette) != len(tt.biomes) || hasData != (len(tt.biomes) > 1) {
+			t.Errorf("%s: biomes = %v", tt.name, s.Biomes)
+		}
+		if next := r.byte(); next != 0x42 {
+			t.Errorf("%s: read past the section, next byte %#x", tt.name, next)
+		}
+	}
+}
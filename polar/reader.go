@@ -0,0 +1,361 @@
+package polar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Tnze/go-mc/nbt"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/emmanuelvlad/slime2schem/schematic"
+	"github.com/emmanuelvlad/slime2schem/slime"
+)
+
+// reader decodes Minestom network buffer types. The first error is kept
+// and every later read returns zero values.
+type reader struct {
+	r   *bytes.Reader
+	err error
+}
+
+func (r *reader) fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+func (r *reader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || n > r.r.Len() {
+		r.fail(fmt.Errorf("length %d exceeds remaining %d bytes", n, r.r.Len()))
+		return nil
+	}
+	b := make([]byte, n)
+	io.ReadFull(r.r, b)
+	return b
+}
+
+func (r *reader) byte() byte {
+	b := r.bytes(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+func (r *reader) bool() bool {
+	return r.byte() != 0
+}
+
+func (r *reader) short() int16 {
+	b := r.bytes(2)
+	if b == nil {
+		return 0
+	}
+	return int16(binary.BigEndian.Uint16(b))
+}
+
+func (r *reader) int() int32 {
+	b := r.bytes(4)
+	if b == nil {
+		return 0
+	}
+	return int32(binary.BigEndian.Uint32(b))
+}
+
+func (r *reader) varInt() int32 {
+	var v uint32
+	for shift := 0; shift < 35; shift += 7 {
+		b := r.byte()
+		if r.err != nil {
+			return 0
+		}
+		v |= uint32(b&0x7F) << shift
+		if b&0x80 == 0 {
+			return int32(v)
+		}
+	}
+	r.fail(errors.New("varint too long"))
+	return 0
+}
+
+func (r *reader) byteArray() []byte {
+	return r.bytes(int(r.varInt()))
+}
+
+func (r *reader) string() string {
+	return string(r.byteArray())
+}
+
+func (r *reader) stringList() []string {
+	n := int(r.varInt())
+	if n < 0 || n > r.r.Len() {
+		r.fail(fmt.Errorf("list length %d out of range", n))
+		return nil
+	}
+	list := make([]string, n)
+	for i := range list {
+		list[i] = r.string()
+	}
+	return list
+}
+
+func (r *reader) longArray() []int64 {
+	n := int(r.varInt())
+	if n < 0 || n*8 > r.r.Len() {
+		r.fail(fmt.Errorf("long array length %d out of range", n))
+		return nil
+	}
+	data := make([]int64, n)
+	for i := range data {
+		data[i] = int64(binary.BigEndian.Uint64(r.bytes(8)))
+	}
+	return data
+}
+
+// compound reads an NBT compound; network NBT (no root name) from
+// versionMinestomNBTReadBreak onwards, a named root before that.
+func (r *reader) compound(version int16) map[string]interface{} {
+	if r.err != nil {
+		return nil
+	}
+	d := nbt.NewDecoder(r.r)
+	d.NetworkFormat(version > versionMinestomNBTReadBreak)
+	var m map[string]interface{}
+	if _, err := d.Decode(&m); err != nil {
+		r.fail(err)
+		return nil
+	}
+	return m
+}
+
+// ReadWorld parses a Polar world. Sections are listed from the world's
+// lowest section upwards, matching the slime layout.
+func ReadWorld(data []byte) (*slime.SlimeWorld, error) {
+	r := &reader{r: bytes.NewReader(data)}
+	if magic := r.int(); r.err == nil && magic != Magic {
+		return nil, fmt.Errorf("invalid magic: 0x%08X (expected 0x%08X)", uint32(magic), uint32(Magic))
+	}
+	version := r.short()
+	if r.err != nil {
+		return nil, fmt.Errorf("reading header: %w", r.err)
+	}
+	if version < versionUnifiedLight || version > Version {
+		return nil, fmt.Errorf("unsupported polar version: %d (supported: %d-%d)", version, versionUnifiedLight, Version)
+	}
+
+	world := &slime.SlimeWorld{WorldVersion: legacyDataVersion}
+	if version >= versionDataConverter {
+		world.WorldVersion = uint32(r.varInt())
+	}
+	compression := r.byte()
+	length := int(r.varInt())
+	if r.err != nil {
+		return nil, fmt.Errorf("reading header: %w", r.err)
+	}
+
+	body := data[len(data)-r.r.Len():]
+	switch compression {
+	case CompressionNone:
+	case CompressionZstd:
+		decoder, err := zstd.NewReader(nil)
+		if err != nil {
+			return nil, err
+		}
+		defer decoder.Close()
+		if body, err = decoder.DecodeAll(body, nil); err != nil {
+			return nil, fmt.Errorf("decompressing world: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown compression type %d", compression)
+	}
+	if len(body) != length {
+		return nil, fmt.Errorf("world data size mismatch: got %d, expected %d", len(body), length)
+	}
+
+	r = &reader{r: bytes.NewReader(body)}
+	minSection, maxSection := int8(r.byte()), int8(r.byte())
+	if maxSection < minSection {
+		return nil, fmt.Errorf("invalid section range %d..%d", minSection, maxSection)
+	}
+	if version > versionWorldUserData {
+		if userData := r.byteArray(); len(userData) > 0 {
+			world.Extra = map[string]interface{}{UserDataKey: userData}
+		}
+	}
+
+	chunkCount := int(r.varInt())
+	if r.err != nil {
+		return nil, fmt.Errorf("reading world header: %w", r.err)
+	}
+	sectionCount := int(maxSection) - int(minSection) + 1
+	world.MinY = int(minSection) * 16
+	for i := 0; i < chunkCount; i++ {
+		chunk := readChunk(r, version, sectionCount)
+		if r.err != nil {
+			return nil, fmt.Errorf("chunk #%d/%d (x=%d z=%d): %w", i, chunkCount, chunk.X, chunk.Z, r.err)
+		}
+		world.Chunks = append(world.Chunks, chunk)
+	}
+	return world, nil
+}
+
+func readChunk(r *reader, version int16, sectionCount int) slime.Chunk {
+	var chunk slime.Chunk
+	chunk.X = r.varInt()
+	chunk.Z = r.varInt()
+
+	for i := 0; i < sectionCount && r.err == nil; i++ {
+		chunk.Sections = append(chunk.Sections, readSection(r, version))
+	}
+
+	n := int(r.varInt())
+	for i := 0; i < n && r.err == nil; i++ {
+		if be := readBlockEntity(r, version, chunk.X, chunk.Z); be != nil {
+			chunk.TileEntities = append(chunk.TileEntities, be)
+		}
+	}
+
+	mask := r.int()
+	for i := 0; i < 32 && r.err == nil; i++ {
+		if mask&(1<<i) == 0 {
+			continue
+		}
+		data := r.longArray()
+		if i < len(heightmapNames) && len(data) > 0 {
+			if chunk.Heightmaps == nil {
+				chunk.Heightmaps = make(map[string]interface{})
+			}
+			chunk.Heightmaps[heightmapNames[i]] = data
+		}
+	}
+
+	if version > versionUserDataOptBlockNBT {
+		if userData := r.byteArray(); len(userData) > 0 {
+			chunk.Extra = map[string]interface{}{UserDataKey: userData}
+		}
+	}
+	return chunk
+}
+
+func readSection(r *reader, version int16) slime.Section {
+	if r.bool() {
+		return slime.NewSection([]slime.BlockState{{Name: "minecraft:air"}}, nil)
+	}
+
+	states := r.stringList()
+	palette := make([]slime.BlockState, len(states))
+	for i, state := range states {
+		name, props := schematic.ParseBlockState(state)
+		if version <= versionShortGrass && name == "minecraft:grass" {
+			name = "minecraft:short_grass"
+		}
+		palette[i] = slime.BlockState{Name: name, Properties: props}
+	}
+	var data []int64
+	if len(palette) > 1 {
+		// Polar packs with as few bits as the palette needs; slime and
+		// vanilla use at least 4.
+		indices := unpack(r.longArray(), bitsFor(len(palette)), blocksPerSection)
+		data = pack(indices, max(bitsFor(len(palette)), 4))
+	}
+	section := slime.NewSection(palette, data)
+
+	biomes := r.stringList()
+	if len(biomes) > 0 {
+		entries := make([]interface{}, len(biomes))
+		for i, b := range biomes {
+			entries[i] = b
+		}
+		section.Biomes = map[string]interface{}{"palette": entries}
+		if len(biomes) > 1 {
+			// Biome data uses the same entry size as vanilla.
+			section.Biomes["data"] = r.longArray()
+		}
+	}
+
+	switch {
+	case version >= versionImprovedLight:
+		section.BlockLight = readLight(r)
+		section.SkyLight = readLight(r)
+	case version > versionUnifiedLight:
+		if r.bool() {
+			section.BlockLight = r.bytes(2048)
+		}
+		if r.bool() {
+			section.SkyLight = r.bytes(2048)
+		}
+	default:
+		if r.bool() {
+			section.BlockLight = r.bytes(2048)
+			section.SkyLight = r.bytes(2048)
+		}
+	}
+	return section
+}
+
+// readLight reads a light content type and, if present, its nibble array.
+// Uniform light is expanded so that it survives conversion to slime.
+func readLight(r *reader) []byte {
+	switch content := r.byte(); content {
+	case lightMissing:
+		return nil
+	case lightEmpty:
+		return make([]byte, 2048)
+	case lightFull:
+		return bytes.Repeat([]byte{0xFF}, 2048)
+	case lightPresent:
+		return r.bytes(2048)
+	default:
+		r.fail(fmt.Errorf("unknown light content type %d", content))
+		return nil
+	}
+}
+
+// readBlockEntity reads a block entity and rebuilds its chunk NBT form,
+// with id and absolute x, y, z.
+func readBlockEntity(r *reader, version int16, chunkX, chunkZ int32) map[string]interface{} {
+	pos := r.int()
+	var id string
+	if r.bool() {
+		id = r.string()
+	}
+	var data map[string]interface{}
+	if version <= versionUserDataOptBlockNBT || r.bool() {
+		data = r.compound(version)
+	}
+	if r.err != nil {
+		return nil
+	}
+
+	be := make(map[string]interface{}, len(data)+4)
+	for k, v := range data {
+		be[k] = v
+	}
+	if id != "" {
+		be["id"] = id
+	}
+	x, y, z := unpackBlockIndex(pos)
+	be["x"] = chunkX*16 + x
+	be["y"] = y
+	be["z"] = chunkZ*16 + z
+	return be
+}
+
+// unpackBlockIndex splits a Minestom chunk block index: x in bits 0-3, the
+// magnitude of y in bits 4-26 with its sign in bit 27, z in bits 28-31.
+func unpackBlockIndex(index int32) (x, y, z int32) {
+	u := uint32(index)
+	x = int32(u & 0xF)
+	y = int32(u & 0x07FFFFF0 >> 4)
+	if u>>27&1 == 1 {
+		y = -y
+	}
+	z = int32(u >> 28 & 0xF)
+	return x, y, z
+}
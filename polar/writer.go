@@ -0,0 +1,260 @@
+package polar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/emmanuelvlad/slime2schem/internal/nbtenc"
+	"github.com/emmanuelvlad/slime2schem/schematic"
+	"github.com/emmanuelvlad/slime2schem/slime"
+)
+
+// DefaultMinY is the overworld's lowest block Y.
+const DefaultMinY = -64
+
+// WriteOptions controls how a world is written.
+type WriteOptions struct {
+	// MinY is the block Y of the lowest section Polar records for the
+	// world (a multiple of 16); use 0 for nether and end worlds.
+	MinY int
+	// Uncompressed stores the world data without zstd compression.
+	Uncompressed bool
+}
+
+// writer encodes Minestom network buffer types.
+type writer struct {
+	bytes.Buffer
+}
+
+func (w *writer) bool(v bool) {
+	if v {
+		w.WriteByte(1)
+	} else {
+		w.WriteByte(0)
+	}
+}
+
+func (w *writer) int(v int32) {
+	binary.Write(w, binary.BigEndian, v)
+}
+
+func (w *writer) varInt(v int32) {
+	u := uint32(v)
+	for u >= 0x80 {
+		w.WriteByte(byte(u) | 0x80)
+		u >>= 7
+	}
+	w.WriteByte(byte(u))
+}
+
+func (w *writer) byteArray(b []byte) {
+	w.varInt(int32(len(b)))
+	w.Write(b)
+}
+
+func (w *writer) string(s string) {
+	w.varInt(int32(len(s)))
+	w.WriteString(s)
+}
+
+func (w *writer) stringList(list []string) {
+	w.varInt(int32(len(list)))
+	for _, s := range list {
+		w.string(s)
+	}
+}
+
+func (w *writer) longArray(data []int64) {
+	w.varInt(int32(len(data)))
+	binary.Write(w, binary.BigEndian, data)
+}
+
+// compound writes an NBT compound in network format (no root name).
+func (w *writer) compound(m map[string]interface{}) error {
+	nw := nbtenc.NewWriter(w)
+	nw.Write([]byte{nbtenc.TagCompound})
+	nw.WritePayload(m)
+	return nw.Err()
+}
+
+// WriteWorld encodes world in Polar format. Every chunk gets the same
+// number of sections, the most any chunk has; missing ones are written
+// empty. Entities and scheduled ticks are dropped, as Polar does not store
+// them.
+func WriteWorld(world *slime.SlimeWorld, opts WriteOptions) ([]byte, error) {
+	if opts.MinY%16 != 0 {
+		return nil, fmt.Errorf("min Y %d is not a multiple of 16", opts.MinY)
+	}
+	sectionCount := 1
+	for i := range world.Chunks {
+		sectionCount = max(sectionCount, len(world.Chunks[i].Sections))
+	}
+	minSection := opts.MinY / 16
+	maxSection := minSection + sectionCount - 1
+	if minSection < -128 || maxSection > 127 {
+		return nil, fmt.Errorf("section range %d..%d does not fit in a byte", minSection, maxSection)
+	}
+
+	var body writer
+	body.WriteByte(byte(int8(minSection)))
+	body.WriteByte(byte(int8(maxSection)))
+	userData, _ := world.Extra[UserDataKey].([]byte)
+	body.byteArray(userData)
+	body.varInt(int32(len(world.Chunks)))
+	for i := range world.Chunks {
+		c := &world.Chunks[i]
+		if err := writeChunk(&body, c, sectionCount); err != nil {
+			return nil, fmt.Errorf("chunk %d,%d: %w", c.X, c.Z, err)
+		}
+	}
+
+	var out writer
+	out.int(Magic)
+	binary.Write(&out, binary.BigEndian, int16(Version))
+	out.varInt(int32(world.WorldVersion))
+	if opts.Uncompressed {
+		out.WriteByte(CompressionNone)
+		out.byteArray(body.Bytes())
+		return out.Bytes(), nil
+	}
+	encoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer encoder.Close()
+	out.WriteByte(CompressionZstd)
+	out.varInt(int32(body.Len()))
+	out.Write(encoder.EncodeAll(body.Bytes(), nil))
+	return out.Bytes(), nil
+}
+
+func writeChunk(w *writer, c *slime.Chunk, sectionCount int) error {
+	w.varInt(c.X)
+	w.varInt(c.Z)
+	for i := 0; i < sectionCount; i++ {
+		if i >= len(c.Sections) || len(c.Sections[i].BlockPalette) == 0 {
+			w.bool(true)
+			continue
+		}
+		if err := writeSection(w, &c.Sections[i]); err != nil {
+			return fmt.Errorf("section %d: %w", i, err)
+		}
+	}
+
+	w.varInt(int32(len(c.TileEntities)))
+	for _, be := range c.TileEntities {
+		if err := writeBlockEntity(w, be); err != nil {
+			return fmt.Errorf("block entity: %w", err)
+		}
+	}
+
+	var mask int32
+	for i, name := range heightmapNames {
+		if data, ok := c.Heightmaps[name].([]int64); ok && len(data) > 0 {
+			mask |= 1 << i
+		}
+	}
+	w.int(mask)
+	for i, name := range heightmapNames {
+		if mask&(1<<i) != 0 {
+			w.longArray(c.Heightmaps[name].([]int64))
+		}
+	}
+
+	userData, _ := c.Extra[UserDataKey].([]byte)
+	w.byteArray(userData)
+	return nil
+}
+
+func writeSection(w *writer, s *slime.Section) error {
+	w.bool(false)
+
+	states := make([]string, len(s.BlockPalette))
+	for i, bs := range s.BlockPalette {
+		states[i] = schematic.BlockStateString(bs.Name, bs.Properties)
+	}
+	w.stringList(states)
+	if len(states) > 1 {
+		w.longArray(pack(sectionIndices(s), bitsFor(len(states))))
+	}
+
+	biomes := []string{"minecraft:plains"}
+	var biomeData []int64
+	if entries, ok := s.Biomes["palette"].([]interface{}); ok && len(entries) > 0 {
+		biomes = biomes[:0]
+		for _, e := range entries {
+			name, ok := e.(string)
+			if !ok {
+				return fmt.Errorf("biome palette entry is %T, not a string", e)
+			}
+			biomes = append(biomes, name)
+		}
+		biomeData, _ = s.Biomes["data"].([]int64)
+	}
+	w.stringList(biomes)
+	if len(biomes) > 1 {
+		if len(biomeData) == 0 {
+			biomeData = pack(make([]int, biomesPerSection), bitsFor(len(biomes)))
+		}
+		w.longArray(biomeData)
+	}
+
+	writeLight(w, s.BlockLight)
+	writeLight(w, s.SkyLight)
+	return nil
+}
+
+func writeLight(w *writer, light []byte) {
+	if len(light) != 2048 {
+		w.WriteByte(lightMissing)
+		return
+	}
+	w.WriteByte(lightPresent)
+	w.Write(light)
+}
+
+// writeBlockEntity writes a block entity's position, id and remaining
+// data; x, y, z and id are not repeated in the data.
+func writeBlockEntity(w *writer, be map[string]interface{}) error {
+	x, _ := be["x"].(int32)
+	y, _ := be["y"].(int32)
+	z, _ := be["z"].(int32)
+	w.int(packBlockIndex(x, y, z))
+
+	id, ok := be["id"].(string)
+	w.bool(ok)
+	if ok {
+		w.string(id)
+	}
+
+	data := make(map[string]interface{}, len(be))
+	for k, v := range be {
+		switch k {
+		case "id", "x", "y", "z", "keepPacked":
+		default:
+			data[k] = v
+		}
+	}
+	w.bool(len(data) > 0)
+	if len(data) > 0 {
+		return w.compound(data)
+	}
+	return nil
+}
+
+// packBlockIndex is the inverse of unpackBlockIndex, taking x and z
+// relative to the chunk.
+func packBlockIndex(x, y, z int32) int32 {
+	index := uint32(x & 0xF)
+	if y > 0 {
+		index |= uint32(y) << 4 & 0x07FFFFF0
+	} else {
+		index |= uint32(-y) << 4 & 0x07FFFFF0
+		index |= 1 << 27
+	}
+	index |= uint32(z&0xF) << 28
+	return int32(index)
+}
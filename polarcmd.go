@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/emmanuelvlad/slime2schem/polar"
+)
+
+// runPolar implements "slime2schem polar".
+func runPolar(args []string) int {
+	fs := flag.NewFlagSet("polar", flag.ExitOnError)
+	minY := fs.Int("min-y", polar.DefaultMinY, "Block Y of the lowest section: -64 for the overworld, 0 for the nether and end")
+	uncompressed := fs.Bool("uncompressed", false, "Store the world without zstd compression")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: slime2schem polar [-min-y Y] [-uncompressed] <world.slime|world dir> <output.polar>\n")
+		fmt.Fprintf(os.Stderr, "\nWrites a world in the Polar format used by Minestom servers.\n")
+		fmt.Fprintf(os.Stderr, "Entities and scheduled ticks are not stored by Polar and are dropped.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	world, err := readWorld(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFailure
+	}
+
+	data, err := polar.WriteWorld(world, polar.WriteOptions{MinY: *minY, Uncompressed: *uncompressed})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error encoding polar world: %v\n", err)
+		return exitFailure
+	}
+	if err := os.WriteFile(fs.Arg(1), data, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing output file: %v\n", err)
+		return exitFailure
+	}
+	fmt.Printf("Wrote %d chunks (data version %d) to %s (%d bytes)\n", len(world.Chunks), world.WorldVersion, fs.Arg(1), len(data))
+	return exitOK
+}
@@ -0,0 +1,107 @@
+package render
+
+import (
+	_ "embed"
+	"fmt"
+	"image/color"
+	"sync"
+
+	"github.com/emmanuelvlad/slime2schem/internal/blockmap"
+)
+
+//go:embed block_colors.txt
+var blockColorsTxt string
+
+// mapColors is the game's map colour palette, keyed by the names used in
+// block_colors.txt. "none" is fully transparent.
+var mapColors = map[string]color.NRGBA{
+	"none":                   {},
+	"grass":                  rgb(0x7FB238),
+	"sand":                   rgb(0xF7E9A3),
+	"wool":                   rgb(0xC7C7C7),
+	"fire":                   rgb(0xFF0000),
+	"ice":                    rgb(0xA0A0FF),
+	"metal":                  rgb(0xA7A7A7),
+	"plant":                  rgb(0x007C00),
+	"snow":                   rgb(0xFFFFFF),
+	"clay":                   rgb(0xA4A8B8),
+	"dirt":                   rgb(0x976D4D),
+	"stone":                  rgb(0x707070),
+	"water":                  rgb(0x4040FF),
+	"wood":                   rgb(0x8F7748),
+	"quartz":                 rgb(0xFFFCF5),
+	"color_orange":           rgb(0xD87F33),
+	"color_magenta":          rgb(0xB24CD8),
+	"color_light_blue":       rgb(0x6699D8),
+	"color_yellow":           rgb(0xE5E533),
+	"color_light_green":      rgb(0x7FCC19),
+	"color_pink":             rgb(0xF27FA5),
+	"color_gray":             rgb(0x4C4C4C),
+	"color_light_gray":       rgb(0x999999),
+	"color_cyan":             rgb(0x4C7F99),
+	"color_purple":           rgb(0x7F3FB2),
+	"color_blue":             rgb(0x334CB2),
+	"color_brown":            rgb(0x664C33),
+	"color_green":            rgb(0x667F33),
+	"color_red":              rgb(0x993333),
+	"color_black":            rgb(0x191919),
+	"gold":                   rgb(0xFAEE4D),
+	"diamond":                rgb(0x5CDBD5),
+	"lapis":                  rgb(0x4A80FF),
+	"emerald":                rgb(0x00D93A),
+	"podzol":                 rgb(0x815631),
+	"nether":                 rgb(0x700200),
+	"terracotta_white":       rgb(0xD1B1A1),
+	"terracotta_orange":      rgb(0x9F5224),
+	"terracotta_magenta":     rgb(0x95576C),
+	"terracotta_light_blue":  rgb(0x706C8A),
+	"terracotta_yellow":      rgb(0xBA8524),
+	"terracotta_light_green": rgb(0x677535),
+	"terracotta_pink":        rgb(0xA04D4E),
+	"terracotta_gray":        rgb(0x392923),
+	"terracotta_light_gray":  rgb(0x876B62),
+	"terracotta_cyan":        rgb(0x575C5C),
+	"terracotta_purple":      rgb(0x7A4958),
+	"terracotta_blue":        rgb(0x4C3E5C),
+	"terracotta_brown":       rgb(0x4C3223),
+	"terracotta_green":       rgb(0x4C522A),
+	"terracotta_red":         rgb(0x8E3C2E),
+	"terracotta_black":       rgb(0x251610),
+	"crimson_nylium":         rgb(0xBD3031),
+	"crimson_stem":           rgb(0x943F61),
+	"crimson_hyphae":         rgb(0x5C191D),
+	"warped_nylium":          rgb(0x167E86),
+	"warped_stem":            rgb(0x3A8E8C),
+	"warped_hyphae":          rgb(0x562C3E),
+	"warped_wart_block":      rgb(0x14B485),
+	"deepslate":              rgb(0x646464),
+	"raw_iron":               rgb(0xD8AF93),
+	"glow_lichen":            rgb(0x7FA796),
+}
+
+// unknownColor is used for blocks missing from the table.
+var unknownColor = mapColors["stone"]
+
+func rgb(v uint32) color.NRGBA {
+	return color.NRGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xFF}
+}
+
+var colorTable = sync.OnceValue(func() *blockmap.Table[color.NRGBA] {
+	return blockmap.MustParse("block_colors.txt", blockColorsTxt, parseColor)
+})
+
+func parseColor(s string) (color.NRGBA, error) {
+	c, ok := mapColors[s]
+	if !ok {
+		return color.NRGBA{}, fmt.Errorf("unknown map colour %q", s)
+	}
+	return c, nil
+}
+
+// BlockColor returns the colour of a block state (as built by
+// schematic.BlockStateString) seen from above. See-through blocks such as
+// air, glass and torches have zero alpha. ok is false when the state is
+// not in the embedded table.
+func BlockColor(state string) (c color.NRGBA, ok bool) {
+	return colorTable().Lookup(state)
+}
@@ -0,0 +1,227 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/emmanuelvlad/slime2schem/schematic"
+)
+
+// IsoOptions controls an isometric render.
+type IsoOptions struct {
+	// Rotation turns the build clockwise, seen from above, in quarter
+	// turns (0-3). At 0 the viewer looks from the south-east.
+	Rotation int
+	// Scale is the size of a block: each one is drawn 4*Scale pixels wide
+	// and tall. Zero means 1.
+	Scale int
+}
+
+// Brightness (out of 255) of each visible face, so the three sides of a
+// block stay distinguishable.
+const (
+	brightTop   = 255
+	brightLeft  = 204 // south face at rotation 0
+	brightRight = 153 // east face at rotation 0
+)
+
+// waterAlpha is the opacity of a water face.
+const waterAlpha = 150
+
+// Isometric draws the schematic as an isometric view in which every block
+// is a cube: the top and the two faces towards the viewer are drawn with
+// the block's map colour (see BlockColor), darker on the sides.
+// See-through blocks are skipped, water is drawn translucent, and the
+// image is cropped to what was drawn.
+func Isometric(s *schematic.Schematic, opts IsoOptions) (*image.NRGBA, error) {
+	if opts.Rotation < 0 || opts.Rotation > 3 {
+		return nil, fmt.Errorf("invalid rotation %d (expected 0-3)", opts.Rotation)
+	}
+	scale := max(opts.Scale, 1)
+	v := newIsoView(s, opts.Rotation)
+	if v.width == 0 || v.height == 0 || v.length == 0 {
+		return nil, errors.New("schematic is empty")
+	}
+	sprite := newCubeSprite(scale)
+
+	// Tile origins range over x-z in [-(length-1), width-1] and
+	// x+z-2y in [-2(height-1), width+length-2], in units of 2*scale and
+	// scale pixels.
+	tile := 4 * scale
+	imgW := (v.width + v.length) * 2 * scale
+	imgH := (v.width+v.length)*scale + 2*scale*v.height
+	shiftX := (v.length - 1) * 2 * scale
+	shiftY := (v.height - 1) * 2 * scale
+	img := image.NewNRGBA(image.Rect(0, 0, imgW, imgH))
+	drawn := image.Rectangle{}
+
+	// Cubes whose x+y+z is equal never overlap on screen, and a cube can
+	// only hide cubes with a smaller sum, so drawing by increasing sum is
+	// back to front.
+	for sum := 0; sum <= v.width+v.height+v.length-3; sum++ {
+		for y := max(0, sum-(v.width-1)-(v.length-1)); y <= min(v.height-1, sum); y++ {
+			for x := max(0, sum-y-(v.length-1)); x <= min(v.width-1, sum-y); x++ {
+				z := sum - y - x
+				b := v.block(x, y, z)
+				if b.color.A == 0 {
+					continue
+				}
+				top := !v.hides(x, y+1, z, b)
+				left := !v.hides(x, y, z+1, b)
+				right := !v.hides(x+1, y, z, b)
+				if !top && !left && !right {
+					continue
+				}
+				ox := (x-z)*2*scale + shiftX
+				oy := (x+z)*scale - y*2*scale + shiftY
+				for py := 0; py < tile; py++ {
+					for px := 0; px < tile; px++ {
+						var shade int
+						switch sprite[py*tile+px] {
+						case faceTop:
+							if !top {
+								continue
+							}
+							shade = brightTop
+						case faceLeft:
+							if !left {
+								continue
+							}
+							shade = brightLeft
+						case faceRight:
+							if !right {
+								continue
+							}
+							shade = brightRight
+						default:
+							continue
+						}
+						c := darken(b.color, shade)
+						if b.water {
+							c = over(c, waterAlpha, img.NRGBAAt(ox+px, oy+py))
+						}
+						img.SetNRGBA(ox+px, oy+py, c)
+					}
+				}
+				drawn = drawn.Union(image.Rect(ox, oy, ox+tile, oy+tile))
+			}
+		}
+	}
+	if drawn.Empty() {
+		return nil, errors.New("schematic has no visible blocks")
+	}
+	return img.SubImage(drawn).(*image.NRGBA), nil
+}
+
+// isoBlock is a palette entry's colour and kind.
+type isoBlock struct {
+	color color.NRGBA
+	water bool
+}
+
+// isoView reads a schematic through a rotation.
+type isoView struct {
+	s                     *schematic.Schematic
+	rotation              int
+	width, height, length int // rotated dimensions
+	blocks                []isoBlock
+}
+
+func newIsoView(s *schematic.Schematic, rotation int) *isoView {
+	v := &isoView{s: s, rotation: rotation, width: s.Width, height: s.Height, length: s.Length}
+	if rotation%2 == 1 {
+		v.width, v.length = s.Length, s.Width
+	}
+	for _, state := range s.PaletteNames() {
+		c, ok := BlockColor(state)
+		if !ok {
+			c = unknownColor
+		}
+		v.blocks = append(v.blocks, isoBlock{color: c, water: c == waterColor})
+	}
+	return v
+}
+
+// block returns the block at rotated coordinates (x, y, z); outside the
+// schematic is air.
+func (v *isoView) block(x, y, z int) isoBlock {
+	if x < 0 || x >= v.width || z < 0 || z >= v.length {
+		return isoBlock{}
+	}
+	var sx, sz int
+	switch v.rotation {
+	case 0:
+		sx, sz = x, z
+	case 1:
+		sx, sz = z, v.s.Length-1-x
+	case 2:
+		sx, sz = v.s.Width-1-x, v.s.Length-1-z
+	case 3:
+		sx, sz = v.s.Width-1-z, x
+	}
+	return v.blocks[v.s.PaletteIndexAt(sx, y, sz)]
+}
+
+// hides reports whether the block at (x, y, z) covers the face of b
+// touching it: opaque blocks cover every face, and water covers water.
+func (v *isoView) hides(x, y, z int, b isoBlock) bool {
+	n := v.block(x, y, z)
+	if n.color.A == 0 {
+		return false
+	}
+	return !n.water || b.water
+}
+
+// Pixel labels of a cube sprite.
+const (
+	faceNone = iota
+	faceTop
+	faceLeft
+	faceRight
+)
+
+// newCubeSprite labels each pixel of a 4*scale square tile with the cube
+// face it belongs to: a top rhombus 4*scale wide and 2*scale tall, over
+// two side faces 2*scale wide and tall.
+func newCubeSprite(scale int) []uint8 {
+	tile := 4 * scale
+	s := float64(scale)
+	sprite := make([]uint8, tile*tile)
+	for py := 0; py < tile; py++ {
+		for px := 0; px < tile; px++ {
+			x, y := float64(px)+0.5, float64(py)+0.5
+			var face uint8
+			switch {
+			case absf(x-2*s)/2+absf(y-s) <= s:
+				face = faceTop
+			case x < 2*s && y >= s+x/2 && y <= 3*s+x/2:
+				face = faceLeft
+			case x >= 2*s && y >= 2*s-(x-2*s)/2 && y <= 4*s-(x-2*s)/2:
+				face = faceRight
+			}
+			sprite[py*tile+px] = face
+		}
+	}
+	return sprite
+}
+
+func absf(f float64) float64 {
+	if f < 0 {
+		return -f
+	}
+	return f
+}
+
+// over draws c with the given alpha (out of 255) over dst.
+func over(c color.NRGBA, alpha int, dst color.NRGBA) color.NRGBA {
+	if dst.A == 0 {
+		c.A = uint8(alpha)
+		return c
+	}
+	mix := func(a, b uint8) uint8 {
+		return uint8((int(a)*alpha + int(b)*(255-alpha) + 127) / 255)
+	}
+	return color.NRGBA{R: mix(c.R, dst.R), G: mix(c.G, dst.G), B: mix(c.B, dst.B), A: 0xFF}
+}
@@ -0,0 +1,54 @@
+package render
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/emmanuelvlad/slime2schem/schematic"
+)
+
+func TestIsometric(t *testing.T) {
+	s := schematic.NewSchematic(2, 2, 1, 3953)
+	s.SetBlock(0, 0, 0, "minecraft:stone")
+	s.SetBlock(1, 0, 0, "minecraft:stone")
+	// See-through blocks are not drawn.
+	s.SetBlock(0, 1, 0, "minecraft:glass")
+
+	img, err := Isometric(s, IsoOptions{Scale: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Two cubes along X, each 8 pixels square, offset by 4 across and 2 down.
+	if b := img.Bounds(); b.Dx() != 12 || b.Dy() != 10 {
+		t.Errorf("image is %dx%d, want 12x10", b.Dx(), b.Dy())
+	}
+
+	stone, _ := BlockColor("minecraft:stone")
+	want := map[color.NRGBA]bool{
+		darken(stone, brightTop):   true,
+		darken(stone, brightLeft):  true,
+		darken(stone, brightRight): true,
+	}
+	seen := make(map[color.NRGBA]bool)
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if c := img.NRGBAAt(x, y); c.A != 0 {
+				if !want[c] {
+					t.Fatalf("pixel %d,%d = %v, not a stone face", x, y, c)
+				}
+				seen[c] = true
+			}
+		}
+	}
+	if len(seen) != 3 {
+		t.Errorf("drew %d of the 3 stone faces", len(seen))
+	}
+
+	if _, err := Isometric(s, IsoOptions{Rotation: 4}); err == nil {
+		t.Error("rotation 4 accepted")
+	}
+	if _, err := Isometric(schematic.NewSchematic(1, 1, 1, 3953), IsoOptions{}); err == nil {
+		t.Error("schematic of air rendered")
+	}
+}
@@ -0,0 +1,254 @@
+package render
+
+import (
+	"encoding/csv"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/emmanuelvlad/slime2schem/schematic"
+)
+
+// legendKeys are the characters used to mark blocks in ASCII and CSV
+// layers, most common block first. "." is reserved for air.
+const legendKeys = "#@%&*+=-:;!?$~^<>/\\|ox0123456789abcdefghijklmnpqrstuvwyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// airKey marks air in ASCII layers.
+const airKey = "."
+
+// Legend assigns every block state in a schematic a key and a colour, so
+// that its layers can be drawn and read back against one table.
+type Legend struct {
+	Entries []LegendEntry
+	// entry maps schematic palette indices to Entries indices; -1 is air.
+	entry    []int
+	keyWidth int
+}
+
+// LegendEntry is a block state's key, colour and number of blocks.
+type LegendEntry struct {
+	Key   string
+	Color color.NRGBA
+	State string
+	Count int
+}
+
+// Shades tried, in order, to tell apart blocks with the same map colour.
+var legendShades = []int{255, 220, 180, 135}
+
+// NewLegend builds the legend of a schematic. Entries are sorted by
+// descending count, then by state. Each starts from the block's map colour
+// (see BlockColor); see-through blocks use the wool colour, and blocks
+// whose colour is already taken get a darker shade of it, or failing that
+// a generated colour, so every entry's colour is unique.
+func NewLegend(s *schematic.Schematic) *Legend {
+	names := s.PaletteNames()
+	counts := make([]int, len(names))
+	for y := 0; y < s.Height; y++ {
+		for z := 0; z < s.Length; z++ {
+			for x := 0; x < s.Width; x++ {
+				counts[s.PaletteIndexAt(x, y, z)]++
+			}
+		}
+	}
+
+	l := &Legend{entry: make([]int, len(names)), keyWidth: 1}
+	var order []int
+	for i, state := range names {
+		l.entry[i] = -1
+		name, _, _ := strings.Cut(state, "[")
+		if counts[i] > 0 && name != "minecraft:air" && name != "minecraft:cave_air" && name != "minecraft:void_air" {
+			order = append(order, i)
+		}
+	}
+	sort.Slice(order, func(a, b int) bool {
+		if counts[order[a]] != counts[order[b]] {
+			return counts[order[a]] > counts[order[b]]
+		}
+		return names[order[a]] < names[order[b]]
+	})
+
+	if len(order) > len(legendKeys) {
+		l.keyWidth = 2
+	}
+	used := make(map[color.NRGBA]bool)
+	for n, i := range order {
+		l.entry[i] = len(l.Entries)
+		l.Entries = append(l.Entries, LegendEntry{
+			Key:   legendKey(n, l.keyWidth),
+			Color: uniqueColor(names[i], used),
+			State: names[i],
+			Count: counts[i],
+		})
+	}
+	return l
+}
+
+func legendKey(n, width int) string {
+	if width == 1 {
+		return legendKeys[n : n+1]
+	}
+	k := len(legendKeys)
+	return string([]byte{legendKeys[n/k%k], legendKeys[n%k]})
+}
+
+func uniqueColor(state string, used map[color.NRGBA]bool) color.NRGBA {
+	base, ok := BlockColor(state)
+	if !ok {
+		base = unknownColor
+	}
+	if base.A == 0 {
+		base = mapColors["wool"]
+	}
+	for _, shade := range legendShades {
+		if c := darken(base, shade); !used[c] {
+			used[c] = true
+			return c
+		}
+	}
+	// Walk the hue circle by the golden angle until a free colour turns up.
+	for i := len(used); ; i++ {
+		h := float64(i) * 137.508
+		c := hsv(h-360*float64(int(h/360)), 0.65, 0.85)
+		if !used[c] {
+			used[c] = true
+			return c
+		}
+	}
+}
+
+// hsv converts a hue in degrees and saturation and value in 0-1.
+func hsv(h, s, v float64) color.NRGBA {
+	c := v * s
+	hp := h / 60
+	x := c * (1 - absf(hp-2*float64(int(hp/2))-1))
+	var r, g, b float64
+	switch int(hp) {
+	case 0:
+		r, g = c, x
+	case 1:
+		r, g = x, c
+	case 2:
+		g, b = c, x
+	case 3:
+		g, b = x, c
+	case 4:
+		r, b = x, c
+	default:
+		r, b = c, x
+	}
+	m := v - c
+	return color.NRGBA{R: uint8((r + m) * 255), G: uint8((g + m) * 255), B: uint8((b + m) * 255), A: 0xFF}
+}
+
+// Empty reports whether layer y of s holds nothing but air.
+func (l *Legend) Empty(s *schematic.Schematic, y int) bool {
+	for z := 0; z < s.Length; z++ {
+		for x := 0; x < s.Width; x++ {
+			if l.entry[s.PaletteIndexAt(x, y, z)] >= 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Grid lines between blocks are black at this opacity (out of 255).
+const gridAlpha = 0x40
+
+// Slice draws layer y of s from above with north at the top, each block a
+// scale x scale square in its legend colour and air left transparent. From
+// scale 4 upwards a grid line separates the blocks.
+func (l *Legend) Slice(s *schematic.Schematic, y, scale int) *image.NRGBA {
+	scale = max(scale, 1)
+	img := image.NewNRGBA(image.Rect(0, 0, s.Width*scale, s.Length*scale))
+	grid := scale >= 4
+	for z := 0; z < s.Length; z++ {
+		for x := 0; x < s.Width; x++ {
+			e := l.entry[s.PaletteIndexAt(x, y, z)]
+			for py := 0; py < scale; py++ {
+				for px := 0; px < scale; px++ {
+					c := color.NRGBA{}
+					if e >= 0 {
+						c = l.Entries[e].Color
+					}
+					if grid && (px == 0 || py == 0) {
+						c = over(color.NRGBA{}, gridAlpha, c)
+					}
+					img.SetNRGBA(x*scale+px, z*scale+py, c)
+				}
+			}
+		}
+	}
+	return img
+}
+
+// WriteASCII writes layer y of s as one line of keys per row of blocks,
+// north first, with "." for air.
+func (l *Legend) WriteASCII(w io.Writer, s *schematic.Schematic, y int) error {
+	var line strings.Builder
+	for z := 0; z < s.Length; z++ {
+		line.Reset()
+		for x := 0; x < s.Width; x++ {
+			if e := l.entry[s.PaletteIndexAt(x, y, z)]; e >= 0 {
+				line.WriteString(l.Entries[e].Key)
+			} else {
+				line.WriteString(strings.Repeat(airKey, l.keyWidth))
+			}
+		}
+		line.WriteByte('\n')
+		if _, err := io.WriteString(w, line.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteCSV writes layer y of s as CSV, one record of keys per row of
+// blocks, north first, with empty fields for air.
+func (l *Legend) WriteCSV(w io.Writer, s *schematic.Schematic, y int) error {
+	cw := csv.NewWriter(w)
+	record := make([]string, s.Width)
+	for z := 0; z < s.Length; z++ {
+		for x := range record {
+			record[x] = ""
+			if e := l.entry[s.PaletteIndexAt(x, y, z)]; e >= 0 {
+				record[x] = l.Entries[e].Key
+			}
+		}
+		cw.Write(record)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteTable writes the legend as an aligned plain-text table of key,
+// colour, count and block state.
+func (l *Legend) WriteTable(w io.Writer) error {
+	width := 1
+	for _, e := range l.Entries {
+		width = max(width, len(strconv.Itoa(e.Count)))
+	}
+	for _, e := range l.Entries {
+		if _, err := fmt.Fprintf(w, "%-2s  #%02X%02X%02X  %*d  %s\n", e.Key, e.Color.R, e.Color.G, e.Color.B, width, e.Count, e.State); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteLegendCSV writes the legend as CSV with a key,color,count,state
+// header.
+func (l *Legend) WriteLegendCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"key", "color", "count", "state"})
+	for _, e := range l.Entries {
+		cw.Write([]string{e.Key, fmt.Sprintf("#%02X%02X%02X", e.Color.R, e.Color.G, e.Color.B), strconv.Itoa(e.Count), e.State})
+	}
+	cw.Flush()
+	return cw.Error()
+}
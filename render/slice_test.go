@@ -0,0 +1,59 @@
+package render
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/emmanuelvlad/slime2schem/schematic"
+)
+
+// stateRow returns a schematic one block high and deep holding n distinct
+// block states followed by one air block.
+func stateRow(n int) *schematic.Schematic {
+	s := schematic.NewSchematic(n+1, 1, 1, 3953)
+	for x := 0; x < n; x++ {
+		s.SetBlock(x, 0, 0, fmt.Sprintf("minecraft:block_%03d", x))
+	}
+	return s
+}
+
+func TestLegendKeyWidth(t *testing.T) {
+	for _, tt := range []struct {
+		states   int
+		width    int
+		lastKey  string
+		airCells string
+	}{
+		{len(legendKeys), 1, legendKeys[len(legendKeys)-1:], "."},
+		{len(legendKeys) + 1, 2, legendKeys[1:2] + legendKeys[0:1], ".."},
+	} {
+		s := stateRow(tt.states)
+		l := NewLegend(s)
+		if len(l.Entries) != tt.states {
+			t.Fatalf("%d states: %d legend entries", tt.states, len(l.Entries))
+		}
+		keys := make(map[string]bool)
+		colors := make(map[color.NRGBA]bool)
+		for _, e := range l.Entries {
+			if len(e.Key) != tt.width || keys[e.Key] || colors[e.Color] {
+				t.Errorf("%d states: key %q or colour %v is not unique or %d wide", tt.states, e.Key, e.Color, tt.width)
+			}
+			keys[e.Key] = true
+			colors[e.Color] = true
+		}
+		if last := l.Entries[len(l.Entries)-1].Key; last != tt.lastKey {
+			t.Errorf("%d states: last key %q, want %q", tt.states, last, tt.lastKey)
+		}
+
+		var b strings.Builder
+		if err := l.WriteASCII(&b, s, 0); err != nil {
+			t.Fatal(err)
+		}
+		line := strings.TrimSuffix(b.String(), "\n")
+		if len(line) != (tt.states+1)*tt.width || !strings.HasSuffix(line, tt.airCells) {
+			t.Errorf("%d states: ASCII row %q, want %d keys of width %d ending in air", tt.states, line, tt.states+1, tt.width)
+		}
+	}
+}
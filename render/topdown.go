@@ -0,0 +1,250 @@
+// Package render draws slime worlds as images, e.g. for map thumbnails.
+package render
+
+import (
+	"errors"
+	"image"
+	"image/color"
+
+	"github.com/emmanuelvlad/slime2schem/schematic"
+	"github.com/emmanuelvlad/slime2schem/slime"
+)
+
+// Options controls how a world is drawn.
+type Options struct {
+	// Shade lightens or darkens each column by its height relative to the
+	// column north of it, as maps do, so terrain relief is visible.
+	Shade bool
+	// ClearWater draws water as a translucent layer over whatever lies
+	// beneath it, more opaque the deeper it is, instead of a flat colour.
+	ClearWater bool
+}
+
+// Brightness multipliers (out of 255) for a column lower than, level
+// with, and higher than its northern neighbour.
+const (
+	shadeLow    = 180
+	shadeNormal = 220
+	shadeHigh   = 255
+)
+
+// TopDown draws world from above, one pixel per block column, using the
+// highest block in each column that is not see-through. The image covers
+// the chunks' bounding box with north at the top; columns in missing
+// chunks are transparent.
+//
+// A chunk's WORLD_SURFACE heightmap, if it has one, is used as the
+// starting point of the search; otherwise, or where it is out of date, the
+// column is scanned from the top of the chunk.
+func TopDown(world *slime.SlimeWorld, opts Options) (*image.NRGBA, error) {
+	if len(world.Chunks) == 0 {
+		return nil, errors.New("world has no chunks")
+	}
+	minX, minZ := world.Chunks[0].X, world.Chunks[0].Z
+	maxX, maxZ := minX, minZ
+	for _, c := range world.Chunks {
+		minX, maxX = min(minX, c.X), max(maxX, c.X)
+		minZ, maxZ = min(minZ, c.Z), max(maxZ, c.Z)
+	}
+	width, length := int(maxX-minX+1)*16, int(maxZ-minZ+1)*16
+
+	// Surface height of every drawn column, for shading; -1 where nothing
+	// was drawn.
+	heights := make([]int, width*length)
+	for i := range heights {
+		heights[i] = -1
+	}
+	isWater := make([]bool, width*length)
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, length))
+	for i := range world.Chunks {
+		c := &world.Chunks[i]
+		cols := newChunkColors(c)
+		surface := heightmap(c, "WORLD_SURFACE")
+		for z := 0; z < 16; z++ {
+			for x := 0; x < 16; x++ {
+				top := len(c.Sections)*16 - 1
+				if surface != nil {
+					if h := surface[z*16+x]; h > 0 && h-1 <= top && !cols.isAir(x, h-1, z) {
+						top = h - 1
+					}
+				}
+				col, ok := cols.column(x, z, top, opts.ClearWater)
+				if !ok {
+					continue
+				}
+				px, pz := int(c.X-minX)*16+x, int(c.Z-minZ)*16+z
+				img.SetNRGBA(px, pz, col.color)
+				heights[pz*width+px] = col.y
+				isWater[pz*width+px] = col.water
+			}
+		}
+	}
+
+	if opts.Shade {
+		// Walk rows bottom-up so every row still compares against the
+		// unshaded heights of the row above it.
+		for z := length - 1; z >= 0; z-- {
+			for x := 0; x < width; x++ {
+				i := z*width + x
+				if heights[i] < 0 || isWater[i] {
+					continue
+				}
+				shade := shadeNormal
+				if z > 0 && heights[i-width] >= 0 {
+					switch north := heights[i-width]; {
+					case heights[i] > north:
+						shade = shadeHigh
+					case heights[i] < north:
+						shade = shadeLow
+					}
+				}
+				img.SetNRGBA(x, z, darken(img.NRGBAAt(x, z), shade))
+			}
+		}
+	}
+	return img, nil
+}
+
+// chunkColors holds the colour of every palette entry of a chunk's
+// sections, looked up once per chunk rather than once per block.
+type chunkColors struct {
+	chunk  *slime.Chunk
+	colors [][]color.NRGBA
+	water  [][]bool
+	air    [][]bool
+}
+
+var waterColor = mapColors["water"]
+
+func newChunkColors(c *slime.Chunk) *chunkColors {
+	cc := &chunkColors{
+		chunk:  c,
+		colors: make([][]color.NRGBA, len(c.Sections)),
+		water:  make([][]bool, len(c.Sections)),
+		air:    make([][]bool, len(c.Sections)),
+	}
+	for i, s := range c.Sections {
+		cc.colors[i] = make([]color.NRGBA, len(s.BlockPalette))
+		cc.water[i] = make([]bool, len(s.BlockPalette))
+		cc.air[i] = make([]bool, len(s.BlockPalette))
+		for j, bs := range s.BlockPalette {
+			col, ok := BlockColor(schematic.BlockStateString(bs.Name, bs.Properties))
+			if !ok {
+				col = unknownColor
+			}
+			cc.colors[i][j] = col
+			cc.water[i][j] = col == waterColor
+			cc.air[i][j] = bs.Name == "minecraft:air" || bs.Name == "minecraft:cave_air" || bs.Name == "minecraft:void_air"
+		}
+	}
+	return cc
+}
+
+// isAir reports whether the block at (x, y, z) is air. A heightmap that
+// points at air is out of date.
+func (cc *chunkColors) isAir(x, y, z int) bool {
+	s := &cc.chunk.Sections[y>>4]
+	if len(s.BlockPalette) == 0 {
+		return true
+	}
+	return cc.air[y>>4][s.PaletteIndexAt(x, y&15, z)]
+}
+
+// column is the result of looking down one block column.
+type column struct {
+	color color.NRGBA
+	y     int  // height of the drawn surface
+	water bool // the surface is water
+}
+
+// column finds the first block at or below top in column (x, z) that is
+// not see-through. With clearWater, water is blended over the first solid
+// block below it. ok is false if the column holds nothing to draw at or
+// below top.
+func (cc *chunkColors) column(x, z, top int, clearWater bool) (col column, ok bool) {
+	waterTop, depth := -1, 0
+	for y := top; y >= 0; y-- {
+		si := y >> 4
+		s := &cc.chunk.Sections[si]
+		if len(s.BlockPalette) == 0 {
+			y = si * 16 // skip the rest of the empty section
+			continue
+		}
+		idx := s.PaletteIndexAt(x, y&15, z)
+		c := cc.colors[si][idx]
+		if c.A == 0 {
+			continue
+		}
+		if cc.water[si][idx] {
+			if !clearWater {
+				return column{color: c, y: y, water: true}, true
+			}
+			if waterTop < 0 {
+				waterTop = y
+			}
+			depth++
+			continue
+		}
+		if waterTop < 0 {
+			return column{color: c, y: y}, true
+		}
+		return column{color: blendWater(c, depth), y: waterTop, water: true}, true
+	}
+	if waterTop >= 0 {
+		return column{color: waterColor, y: waterTop, water: true}, true
+	}
+	return column{}, false
+}
+
+// blendWater draws depth blocks of water over c.
+func blendWater(c color.NRGBA, depth int) color.NRGBA {
+	alpha := min(0.4+0.1*float64(depth), 0.9)
+	mix := func(a, b uint8) uint8 {
+		return uint8(float64(a)*alpha + float64(b)*(1-alpha) + 0.5)
+	}
+	return color.NRGBA{
+		R: mix(waterColor.R, c.R),
+		G: mix(waterColor.G, c.G),
+		B: mix(waterColor.B, c.B),
+		A: 0xFF,
+	}
+}
+
+func darken(c color.NRGBA, shade int) color.NRGBA {
+	return color.NRGBA{
+		R: uint8(int(c.R) * shade / 255),
+		G: uint8(int(c.G) * shade / 255),
+		B: uint8(int(c.B) * shade / 255),
+		A: c.A,
+	}
+}
+
+// heightmap decodes one of a chunk's heightmaps into 256 values (one per
+// column, x fastest), or returns nil if the chunk does not have it.
+// Entries do not span longs, and their size is whatever fits the array's
+// length, since it depends on the world's height.
+func heightmap(c *slime.Chunk, name string) []int {
+	data, ok := c.Heightmaps[name].([]int64)
+	if !ok || len(data) == 0 {
+		return nil
+	}
+	bits := 0
+	for b := 1; b <= 32; b++ {
+		perLong := 64 / b
+		if (256+perLong-1)/perLong == len(data) {
+			bits = b
+			break
+		}
+	}
+	if bits == 0 {
+		return nil
+	}
+	perLong := 64 / bits
+	mask := uint64(1)<<bits - 1
+	out := make([]int, 256)
+	for i := range out {
+		out[i] = int(uint64(data[i/perLong]) >> ((i % perLong) * bits) & mask)
+	}
+	return out
+}
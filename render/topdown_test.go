@@ -0,0 +1,89 @@
+package render
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/emmanuelvlad/slime2schem/internal/testworld"
+	"github.com/emmanuelvlad/slime2schem/slime"
+)
+
+// packHeightmap packs 256 heights the way vanilla does, with entries not
+// spanning longs.
+func packHeightmap(heights []int, bits int) []int64 {
+	perLong := 64 / bits
+	data := make([]int64, (256+perLong-1)/perLong)
+	for i, h := range heights {
+		data[i/perLong] |= int64(h) << (i % perLong * bits)
+	}
+	return data
+}
+
+func TestHeightmap(t *testing.T) {
+	heights := make([]int, 256)
+	for i := range heights {
+		heights[i] = i * 7 % 385
+	}
+	// 9 bits for a 384-block world and 10 bits for a taller one.
+	for _, bits := range []int{9, 10} {
+		c := &slime.Chunk{Heightmaps: map[string]interface{}{"WORLD_SURFACE": packHeightmap(heights, bits)}}
+		if got := heightmap(c, "WORLD_SURFACE"); !slices.Equal(got, heights) {
+			t.Errorf("%d bits: heightmap = %v", bits, got)
+		}
+	}
+
+	c := &slime.Chunk{Heightmaps: map[string]interface{}{"WORLD_SURFACE": make([]int64, 5)}}
+	if got := heightmap(c, "WORLD_SURFACE"); got != nil {
+		t.Errorf("heightmap of 5 longs = %v, want nil", got)
+	}
+	if got := heightmap(c, "MOTION_BLOCKING"); got != nil {
+		t.Errorf("missing heightmap = %v, want nil", got)
+	}
+}
+
+func TestTopDown(t *testing.T) {
+	// Column 0,0 is two blocks of water over stone; the heightmap of
+	// column 1,0 points above its surface, at air.
+	section := testworld.Section("minecraft:air", map[[3]int]string{
+		{0, 0, 0}: "minecraft:stone",
+		{0, 1, 0}: "minecraft:water",
+		{0, 2, 0}: "minecraft:water",
+		{1, 0, 0}: "minecraft:stone",
+	})
+	heights := make([]int, 256)
+	heights[1] = 11
+	world := testworld.New(0,
+		slime.Chunk{Sections: []slime.Section{section}, Heightmaps: map[string]interface{}{"WORLD_SURFACE": packHeightmap(heights, 9)}},
+		slime.Chunk{X: 1, Z: 1, Sections: []slime.Section{section}},
+	)
+	stone, _ := BlockColor("minecraft:stone")
+
+	for _, clear := range []bool{false, true} {
+		img, err := TopDown(world, Options{ClearWater: clear})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b := img.Bounds(); b.Dx() != 32 || b.Dy() != 32 {
+			t.Fatalf("image is %dx%d, want 32x32", b.Dx(), b.Dy())
+		}
+		want := waterColor
+		if clear {
+			want = blendWater(stone, 2)
+		}
+		if got := img.NRGBAAt(0, 0); got != want {
+			t.Errorf("clear water %v: water column = %v, want %v", clear, got, want)
+		}
+		if got := img.NRGBAAt(1, 0); got != stone {
+			t.Errorf("column under a stale heightmap = %v, want stone %v", got, stone)
+		}
+		if got := img.NRGBAAt(2, 0); got.A != 0 {
+			t.Errorf("empty column = %v, want transparent", got)
+		}
+		if got := img.NRGBAAt(16, 0); got.A != 0 {
+			t.Errorf("missing chunk = %v, want transparent", got)
+		}
+		if got := img.NRGBAAt(17, 16); got != stone {
+			t.Errorf("second chunk = %v, want stone", got)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/emmanuelvlad/slime2schem/converter"
+	"github.com/emmanuelvlad/slime2schem/render"
+)
+
+// runRender implements "slime2schem render".
+func runRender(args []string) int {
+	fs := flag.NewFlagSet("render", flag.ExitOnError)
+	flat := fs.Bool("flat", false, "Do not shade columns by height")
+	opaqueWater := fs.Bool("opaque-water", false, "Draw water as a flat colour instead of showing what lies beneath it")
+	iso := fs.Bool("iso", false, "Draw an isometric view of the converted schematic instead of a map")
+	rotation := fs.Int("rotate", 0, "Isometric view: quarter turns to rotate the build clockwise (0-3)")
+	scale := fs.Int("scale", 1, "Isometric view: block size, each block is 4*scale pixels wide")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: slime2schem render [-flat] [-opaque-water] <world.slime> [output.png]\n")
+		fmt.Fprintf(os.Stderr, "       slime2schem render -iso [-rotate N] [-scale N] <world.slime> [output.png]\n")
+		fmt.Fprintf(os.Stderr, "\nDraws a top-down map of a world as a PNG, one pixel per block column,\n")
+		fmt.Fprintf(os.Stderr, "or with -iso an isometric view of it.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	input := fs.Arg(0)
+	output := fs.Arg(1)
+	if output == "" {
+		clean := filepath.Clean(input)
+		output = strings.TrimSuffix(clean, filepath.Ext(clean)) + ".png"
+	}
+
+	world, err := readWorld(input)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFailure
+	}
+
+	var img *image.NRGBA
+	if *iso {
+		var result *converter.ConvertResult
+		result, err = converter.Convert(world)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error converting: %v\n", err)
+			return exitFailure
+		}
+		img, err = render.Isometric(result.Schematic, render.IsoOptions{Rotation: *rotation, Scale: *scale})
+	} else {
+		img, err = render.TopDown(world, render.Options{Shade: !*flat, ClearWater: !*opaqueWater})
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error rendering: %v\n", err)
+		return exitFailure
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		fmt.Fprintf(os.Stderr, "Error encoding PNG: %v\n", err)
+		return exitFailure
+	}
+	if err := os.WriteFile(output, buf.Bytes(), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing output file: %v\n", err)
+		return exitFailure
+	}
+	fmt.Printf("Rendered %d x %d image to %s\n", img.Bounds().Dx(), img.Bounds().Dy(), output)
+	return exitOK
+}
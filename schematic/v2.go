@@ -0,0 +1,113 @@
+package schematic
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+
+	"github.com/emmanuelvlad/slime2schem/internal/nbtenc"
+)
+
+// Format is a Sponge Schematic specification version.
+type Format int
+
+const (
+	FormatV2 Format = 2
+	FormatV3 Format = 3
+)
+
+// ParseFormat parses a format name such as "v3" or "3".
+func ParseFormat(name string) (Format, error) {
+	switch name {
+	case "v3", "3":
+		return FormatV3, nil
+	case "v2", "2":
+		return FormatV2, nil
+	default:
+		return 0, fmt.Errorf("unknown schematic format %q (expected v2 or v3)", name)
+	}
+}
+
+// SaveFormat writes the schematic in the given Sponge Schematic version.
+func (s *Schematic) SaveFormat(f Format) ([]byte, error) {
+	switch f {
+	case FormatV3:
+		return s.Save()
+	case FormatV2:
+		return s.SaveV2()
+	default:
+		return nil, fmt.Errorf("unsupported schematic format: %d", f)
+	}
+}
+
+// SaveV2 writes the schematic to gzipped NBT bytes in Sponge Schematic v2
+// format, for WorldEdit versions that predate v3 support.
+//
+// The layout differs from v3: Palette, PaletteMax and BlockData sit at the
+// top level, block entity and entity data is flattened next to Id and Pos,
+// and the paste offset lives in Metadata.WEOffsetX/Y/Z.
+func (s *Schematic) SaveV2() ([]byte, error) {
+	var gzBuf bytes.Buffer
+	gzWriter := gzip.NewWriter(&gzBuf)
+	w := nbtenc.NewWriter(gzWriter)
+
+	// v2 root compound is named "Schematic"
+	w.BeginCompound("Schematic")
+	w.WriteInt("Version", 2)
+	w.WriteInt("DataVersion", s.DataVersion)
+	w.WriteShort("Width", int16(s.Width))
+	w.WriteShort("Height", int16(s.Height))
+	w.WriteShort("Length", int16(s.Length))
+
+	// WorldEdit reads Offset as the minimum corner and computes the paste
+	// origin as Offset - WEOffset, so keep Offset at zero and put the v3
+	// paste offset in WEOffset.
+	w.WriteIntArray("Offset", []int32{0, 0, 0})
+	w.BeginCompound("Metadata")
+	w.WriteInt("WEOffsetX", s.Offset[0])
+	w.WriteInt("WEOffsetY", s.Offset[1])
+	w.WriteInt("WEOffsetZ", s.Offset[2])
+	w.EndCompound()
+
+	w.WriteInt("PaletteMax", int32(len(s.paletteNames)))
+	w.BeginCompound("Palette")
+	for idx, name := range s.paletteNames {
+		w.WriteInt(name, int32(idx))
+	}
+	w.EndCompound()
+
+	writeBlockDataVarints(w, "BlockData", s.blockData)
+	s.blockData = nil
+
+	if len(s.BlockEntities) > 0 {
+		w.BeginList("BlockEntities", nbtenc.TagCompound, len(s.BlockEntities))
+		for _, be := range sortedBlockEntities(s.BlockEntities) {
+			w.WriteIntArray("Pos", be.Pos[:])
+			w.WriteString("Id", be.Id)
+			w.WriteFields(be.Data, "Pos", "Id")
+			w.EndCompound()
+		}
+	}
+
+	if len(s.Entities) > 0 {
+		w.BeginList("Entities", nbtenc.TagCompound, len(s.Entities))
+		for _, e := range sortedEntities(s.Entities) {
+			w.WriteTag("Pos", []interface{}{e.Pos[0], e.Pos[1], e.Pos[2]})
+			w.WriteString("Id", e.Id)
+			w.WriteFields(e.Data, "Pos", "Id")
+			w.EndCompound()
+		}
+	}
+
+	w.EndCompound() // Schematic
+
+	if w.Err() != nil {
+		return nil, fmt.Errorf("encoding schematic NBT: %w", w.Err())
+	}
+
+	if err := gzWriter.Close(); err != nil {
+		return nil, fmt.Errorf("closing gzip writer: %w", err)
+	}
+
+	return gzBuf.Bytes(), nil
+}
@@ -3,11 +3,11 @@ package schematic
 import (
 	"bytes"
 	"compress/gzip"
-	"encoding/binary"
 	"fmt"
-	"io"
+	"sort"
+	"strings"
 
-	"github.com/Tnze/go-mc/nbt"
+	"github.com/emmanuelvlad/slime2schem/internal/nbtenc"
 )
 
 // Schematic represents a Sponge Schematic v3 (.schem) file.
@@ -22,6 +22,9 @@ type Schematic struct {
 	// e.g. "minecraft:stone" -> 0, "minecraft:oak_planks" -> 1
 	Palette map[string]int32
 
+	// paletteNames is the reverse of Palette, indexed by palette index.
+	paletteNames []string
+
 	// blockData stores the palette index for each block position as uint16.
 	// Supports up to 65535 unique block states (practical limit).
 	// Indexed as: x + z*Width + y*Width*Length
@@ -29,6 +32,11 @@ type Schematic struct {
 
 	BlockEntities []BlockEntity
 	Entities      []Entity
+
+	// Scheduled block and fluid ticks. The Sponge format has no place for
+	// them, so Save ignores them; other exporters (e.g. Litematica) use them.
+	BlockTicks []Tick
+	FluidTicks []Tick
 }
 
 // BlockEntity represents a block entity in the schematic.
@@ -45,43 +53,74 @@ type Entity struct {
 	Data map[string]interface{}
 }
 
+// Tick is a scheduled block or fluid update.
+type Tick struct {
+	Pos      [3]int32
+	Id       string // block or fluid id
+	Delay    int32  // ticks until the update runs
+	Priority int32
+}
+
 // NewSchematic creates a new empty schematic with the given dimensions.
 func NewSchematic(width, height, length int, dataVersion int32) *Schematic {
 	totalBlocks := width * height * length
 	return &Schematic{
-		Width:       width,
-		Height:      height,
-		Length:      length,
-		DataVersion: dataVersion,
-		Palette:     map[string]int32{"minecraft:air": 0},
-		blockData:   make([]uint16, totalBlocks),
+		Width:        width,
+		Height:       height,
+		Length:       length,
+		DataVersion:  dataVersion,
+		Palette:      map[string]int32{"minecraft:air": 0},
+		paletteNames: []string{"minecraft:air"},
+		blockData:    make([]uint16, totalBlocks),
 	}
 }
 
 // BlockStateString builds the palette key for a block state.
 // e.g. "minecraft:oak_stairs[facing=north,half=bottom,shape=straight]"
+// Properties are sorted by name so each state has exactly one key.
 func BlockStateString(name string, properties map[string]string) string {
 	if len(properties) == 0 {
 		return name
 	}
 
+	keys := make([]string, 0, len(properties))
+	for k := range properties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
 	buf := make([]byte, 0, 64)
 	buf = append(buf, name...)
 	buf = append(buf, '[')
-	first := true
-	for k, v := range properties {
-		if !first {
+	for i, k := range keys {
+		if i > 0 {
 			buf = append(buf, ',')
 		}
 		buf = append(buf, k...)
 		buf = append(buf, '=')
-		buf = append(buf, v...)
-		first = false
+		buf = append(buf, properties[k]...)
 	}
 	buf = append(buf, ']')
 	return string(buf)
 }
 
+// ParseBlockState splits a block state string built by BlockStateString
+// back into its name and properties.
+func ParseBlockState(state string) (name string, properties map[string]string) {
+	name, rest, ok := strings.Cut(state, "[")
+	if !ok {
+		return name, nil
+	}
+	rest = strings.TrimSuffix(rest, "]")
+	properties = make(map[string]string)
+	for _, pair := range strings.Split(rest, ",") {
+		if k, v, ok := strings.Cut(pair, "="); ok {
+			properties[k] = v
+		}
+	}
+	return name, properties
+}
+
 // SetBlock sets a block at the given coordinates using a block state string.
 func (s *Schematic) SetBlock(x, y, z int, blockState string) {
 	index := x + z*s.Width + y*s.Width*s.Length
@@ -94,11 +133,52 @@ func (s *Schematic) SetBlock(x, y, z int, blockState string) {
 	if !ok {
 		paletteIdx = int32(len(s.Palette))
 		s.Palette[blockState] = paletteIdx
+		s.paletteNames = append(s.paletteNames, blockState)
 	}
 
 	s.blockData[index] = uint16(paletteIdx)
 }
 
+// GetBlock returns the block state string at the given coordinates.
+// Positions outside the schematic are reported as air.
+func (s *Schematic) GetBlock(x, y, z int) string {
+	return s.paletteNames[s.PaletteIndexAt(x, y, z)]
+}
+
+// PaletteNames returns the block state strings indexed by palette index.
+// The returned slice must not be modified.
+func (s *Schematic) PaletteNames() []string {
+	return s.paletteNames
+}
+
+// PaletteIndexAt returns the palette index of the block at the given
+// coordinates. Positions outside the schematic are reported as air (0).
+func (s *Schematic) PaletteIndexAt(x, y, z int) int {
+	if x < 0 || x >= s.Width || y < 0 || y >= s.Height || z < 0 || z >= s.Length {
+		return 0
+	}
+	index := x + z*s.Width + y*s.Width*s.Length
+	if index >= len(s.blockData) {
+		return 0
+	}
+	return int(s.blockData[index])
+}
+
+// BlockCounts returns the number of blocks of each block state, excluding air.
+func (s *Schematic) BlockCounts() map[string]int {
+	counts := make([]int, len(s.paletteNames))
+	for _, idx := range s.blockData {
+		counts[idx]++
+	}
+	out := make(map[string]int, len(counts))
+	for idx, n := range counts {
+		if n > 0 && idx != 0 {
+			out[s.paletteNames[idx]] = n
+		}
+	}
+	return out
+}
+
 // Save writes the schematic to gzipped NBT bytes in Sponge Schematic v3 format.
 //
 // NBT is written manually to avoid large intermediate allocations. The block
@@ -108,56 +188,69 @@ func (s *Schematic) SetBlock(x, y, z int, blockState string) {
 func (s *Schematic) Save() ([]byte, error) {
 	var gzBuf bytes.Buffer
 	gzWriter := gzip.NewWriter(&gzBuf)
-	w := &nbtWriter{w: gzWriter}
+	w := nbtenc.NewWriter(gzWriter)
 
 	// Root compound (empty name — required by WorldEdit/FAWE)
-	w.beginCompound("")
+	w.BeginCompound("")
 
 	// Schematic compound
-	w.beginCompound("Schematic")
-	w.writeInt("Version", 3)
-	w.writeInt("DataVersion", s.DataVersion)
-	w.writeShort("Width", int16(s.Width))
-	w.writeShort("Height", int16(s.Height))
-	w.writeShort("Length", int16(s.Length))
-	w.writeIntArray("Offset", s.Offset[:])
+	w.BeginCompound("Schematic")
+	w.WriteInt("Version", 3)
+	w.WriteInt("DataVersion", s.DataVersion)
+	w.WriteShort("Width", int16(s.Width))
+	w.WriteShort("Height", int16(s.Height))
+	w.WriteShort("Length", int16(s.Length))
+	w.WriteIntArray("Offset", s.Offset[:])
 
 	// Blocks compound
-	w.beginCompound("Blocks")
+	w.BeginCompound("Blocks")
 
 	// Palette — each entry is an Int tag whose name is the block state
-	w.beginCompound("Palette")
-	for name, idx := range s.Palette {
-		w.writeInt(name, idx)
+	// Entries are written in index order so the output is reproducible.
+	w.BeginCompound("Palette")
+	for idx, name := range s.paletteNames {
+		w.WriteInt(name, int32(idx))
 	}
-	w.endCompound()
+	w.EndCompound()
 
 	// Data — varint-encoded block data, streamed directly from blockData
 	// This is the critical optimization: no intermediate []byte allocation.
-	w.writeBlockDataVarints("Data", s.blockData)
+	writeBlockDataVarints(w, "Data", s.blockData)
 	s.blockData = nil // release the 351MB array immediately
 
 	// BlockEntities
 	if len(s.BlockEntities) > 0 {
-		w.writeNamedNBT(struct {
-			BlockEntities []blockEntityNBT `nbt:"BlockEntities"`
-		}{BlockEntities: toBlockEntityNBT(s.BlockEntities)})
+		w.BeginList("BlockEntities", nbtenc.TagCompound, len(s.BlockEntities))
+		for _, be := range sortedBlockEntities(s.BlockEntities) {
+			w.WriteIntArray("Pos", be.Pos[:])
+			w.WriteString("Id", be.Id)
+			if len(be.Data) > 0 {
+				w.WriteTag("Data", be.Data)
+			}
+			w.EndCompound()
+		}
 	}
 
-	w.endCompound() // Blocks
+	w.EndCompound() // Blocks
 
 	// Entities
 	if len(s.Entities) > 0 {
-		w.writeNamedNBT(struct {
-			Entities []entityNBT `nbt:"Entities"`
-		}{Entities: toEntityNBT(s.Entities)})
+		w.BeginList("Entities", nbtenc.TagCompound, len(s.Entities))
+		for _, e := range sortedEntities(s.Entities) {
+			w.WriteTag("Pos", []interface{}{e.Pos[0], e.Pos[1], e.Pos[2]})
+			w.WriteString("Id", e.Id)
+			if len(e.Data) > 0 {
+				w.WriteTag("Data", e.Data)
+			}
+			w.EndCompound()
+		}
 	}
 
-	w.endCompound() // Schematic
-	w.endCompound() // root
+	w.EndCompound() // Schematic
+	w.EndCompound() // root
 
-	if w.err != nil {
-		return nil, fmt.Errorf("encoding schematic NBT: %w", w.err)
+	if w.Err() != nil {
+		return nil, fmt.Errorf("encoding schematic NBT: %w", w.Err())
 	}
 
 	if err := gzWriter.Close(); err != nil {
@@ -167,82 +260,11 @@ func (s *Schematic) Save() ([]byte, error) {
 	return gzBuf.Bytes(), nil
 }
 
-// ---------------------------------------------------------------------------
-// Manual NBT writer — writes raw NBT tags to an io.Writer.
-// Tracks the first error and skips subsequent writes on error.
-// ---------------------------------------------------------------------------
-
-type nbtWriter struct {
-	w   io.Writer
-	err error
-}
-
-// NBT tag type IDs
-const (
-	tagEnd       = 0
-	tagByte      = 1
-	tagShort     = 2
-	tagInt       = 3
-	tagFloat     = 5
-	tagDouble    = 6
-	tagByteArray = 7
-	tagString    = 8
-	tagList      = 9
-	tagCompound  = 10
-	tagIntArray  = 11
-)
-
-func (w *nbtWriter) write(data []byte) {
-	if w.err != nil {
-		return
-	}
-	_, w.err = w.w.Write(data)
-}
-
-func (w *nbtWriter) writeBE(v any) {
-	if w.err != nil {
-		return
-	}
-	w.err = binary.Write(w.w, binary.BigEndian, v)
-}
-
-func (w *nbtWriter) writeTagHeader(tagType byte, name string) {
-	w.write([]byte{tagType})
-	w.writeBE(uint16(len(name)))
-	w.write([]byte(name))
-}
-
-func (w *nbtWriter) beginCompound(name string) {
-	w.writeTagHeader(tagCompound, name)
-}
-
-func (w *nbtWriter) endCompound() {
-	w.write([]byte{tagEnd})
-}
-
-func (w *nbtWriter) writeInt(name string, v int32) {
-	w.writeTagHeader(tagInt, name)
-	w.writeBE(v)
-}
-
-func (w *nbtWriter) writeShort(name string, v int16) {
-	w.writeTagHeader(tagShort, name)
-	w.writeBE(v)
-}
-
-func (w *nbtWriter) writeIntArray(name string, v []int32) {
-	w.writeTagHeader(tagIntArray, name)
-	w.writeBE(int32(len(v)))
-	for _, val := range v {
-		w.writeBE(val)
-	}
-}
-
 // writeBlockDataVarints writes an NBT ByteArray tag whose content is the
 // varint encoding of each uint16 in data. The varints are streamed through
 // a small 4 KB buffer so no large intermediate slice is allocated.
-func (w *nbtWriter) writeBlockDataVarints(name string, data []uint16) {
-	if w.err != nil {
+func writeBlockDataVarints(w *nbtenc.Writer, name string, data []uint16) {
+	if w.Err() != nil {
 		return
 	}
 
@@ -258,8 +280,8 @@ func (w *nbtWriter) writeBlockDataVarints(name string, data []uint16) {
 	}
 
 	// Write tag header + array length
-	w.writeTagHeader(tagByteArray, name)
-	w.writeBE(byteLen)
+	w.WriteTagHeader(nbtenc.TagByteArray, name)
+	w.WriteNumber(byteLen)
 
 	// Second pass: encode varints through a small reusable buffer.
 	buf := make([]byte, 0, 4096)
@@ -272,68 +294,74 @@ func (w *nbtWriter) writeBlockDataVarints(name string, data []uint16) {
 		buf = append(buf, byte(uv))
 
 		if len(buf) >= 4000 {
-			w.write(buf)
+			w.Write(buf)
 			buf = buf[:0]
-			if w.err != nil {
+			if w.Err() != nil {
 				return
 			}
 		}
 	}
 	if len(buf) > 0 {
-		w.write(buf)
+		w.Write(buf)
 	}
 }
 
-// writeNamedNBT encodes a struct's fields as NBT tags and injects them into
-// the current compound. It uses go-mc/nbt for complex nested structures
-// (entities, block entities with arbitrary Data maps), then strips the
-// outer compound wrapper that nbt.Encode adds.
-func (w *nbtWriter) writeNamedNBT(v any) {
-	if w.err != nil {
-		return
-	}
-	var buf bytes.Buffer
-	if err := nbt.NewEncoder(&buf).Encode(v, ""); err != nil {
-		w.err = err
-		return
-	}
-	raw := buf.Bytes()
-	// nbt.Encode wraps in: compound-type(1) + name-len(2) + name(0) + ... + end(1)
-	// Strip the 3-byte header and 1-byte trailing End tag to get inner fields.
-	if len(raw) < 4 {
-		return
-	}
-	w.write(raw[3 : len(raw)-1])
-}
-
-// ---------------------------------------------------------------------------
-// NBT helper structs — used only for entity serialization via go-mc/nbt
-// ---------------------------------------------------------------------------
-
-type blockEntityNBT struct {
-	Pos  [3]int32               `nbt:"Pos"`
-	Id   string                 `nbt:"Id"`
-	Data map[string]interface{} `nbt:"Data,omitempty"`
+// sortedBlockEntities returns block entities ordered by Y, Z, X, so the
+// output does not depend on the order they were added in.
+func sortedBlockEntities(entities []BlockEntity) []BlockEntity {
+	out := append([]BlockEntity(nil), entities...)
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i].Pos, out[j].Pos
+		if a[1] != b[1] {
+			return a[1] < b[1]
+		}
+		if a[2] != b[2] {
+			return a[2] < b[2]
+		}
+		return a[0] < b[0]
+	})
+	return out
 }
 
-type entityNBT struct {
-	Pos  [3]float64             `nbt:"Pos"`
-	Id   string                 `nbt:"Id"`
-	Data map[string]interface{} `nbt:"Data,omitempty"`
+// sortedEntities returns entities ordered by Y, Z, X, then id. Entities at
+// the same position keep their relative order.
+func sortedEntities(entities []Entity) []Entity {
+	out := append([]Entity(nil), entities...)
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i].Pos, out[j].Pos
+		if a[1] != b[1] {
+			return a[1] < b[1]
+		}
+		if a[2] != b[2] {
+			return a[2] < b[2]
+		}
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		return out[i].Id < out[j].Id
+	})
+	return out
 }
 
-func toBlockEntityNBT(entities []BlockEntity) []blockEntityNBT {
-	out := make([]blockEntityNBT, len(entities))
-	for i, be := range entities {
-		out[i] = blockEntityNBT{Pos: be.Pos, Id: be.Id, Data: be.Data}
-	}
-	return out
+// UnmappedState is a block state an exporter could not represent in its
+// target format, with the number of blocks affected.
+type UnmappedState struct {
+	State string `json:"state"`
+	Count int    `json:"count"`
 }
 
-func toEntityNBT(entities []Entity) []entityNBT {
-	out := make([]entityNBT, len(entities))
-	for i, e := range entities {
-		out[i] = entityNBT{Pos: e.Pos, Id: e.Id, Data: e.Data}
+// UnmappedStates turns per-state counts into a list sorted by descending
+// count, then by state.
+func UnmappedStates(counts map[string]int) []UnmappedState {
+	out := make([]UnmappedState, 0, len(counts))
+	for state, n := range counts {
+		out = append(out, UnmappedState{State: state, Count: n})
 	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].State < out[j].State
+	})
 	return out
 }
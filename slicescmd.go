@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/emmanuelvlad/slime2schem/converter"
+	"github.com/emmanuelvlad/slime2schem/render"
+)
+
+// runSlices implements "slime2schem slices".
+func runSlices(args []string) int {
+	fs := flag.NewFlagSet("slices", flag.ExitOnError)
+	scale := fs.Int("scale", 16, "Size of each block in pixels (grid lines are drawn from 4)")
+	ascii := fs.Bool("ascii", false, "Also write each layer as an ASCII grid (layer_<y>.txt)")
+	csvOut := fs.Bool("csv", false, "Also write each layer as a CSV grid (layer_<y>.csv) and the legend as legend.csv")
+	skipEmpty := fs.Bool("skip-empty", false, "Do not write layers that hold only air")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: slime2schem slices [-scale N] [-ascii] [-csv] [-skip-empty] <world.slime> <output dir>\n")
+		fmt.Fprintf(os.Stderr, "\nWrites one PNG per Y level of the converted schematic, bottom layer first,\n")
+		fmt.Fprintf(os.Stderr, "with north at the top, and a legend.txt mapping colours and keys to block states.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	world, err := readWorld(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFailure
+	}
+	result, err := converter.Convert(world)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error converting: %v\n", err)
+		return exitFailure
+	}
+	s := result.Schematic
+
+	outDir := fs.Arg(1)
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFailure
+	}
+
+	legend := render.NewLegend(s)
+	var table bytes.Buffer
+	legend.WriteTable(&table)
+	files := map[string][]byte{"legend.txt": table.Bytes()}
+	if *csvOut {
+		var buf bytes.Buffer
+		legend.WriteLegendCSV(&buf)
+		files["legend.csv"] = buf.Bytes()
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(outDir, name), data, 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", name, err)
+			return exitFailure
+		}
+	}
+
+	digits := len(strconv.Itoa(s.Height - 1))
+	written := 0
+	for y := 0; y < s.Height; y++ {
+		if *skipEmpty && legend.Empty(s, y) {
+			continue
+		}
+		base := filepath.Join(outDir, fmt.Sprintf("layer_%0*d", digits, y))
+
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, legend.Slice(s, y, *scale)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding PNG: %v\n", err)
+			return exitFailure
+		}
+		layer := map[string][]byte{".png": buf.Bytes()}
+		if *ascii {
+			var buf bytes.Buffer
+			legend.WriteASCII(&buf, s, y)
+			layer[".txt"] = buf.Bytes()
+		}
+		if *csvOut {
+			var buf bytes.Buffer
+			legend.WriteCSV(&buf, s, y)
+			layer[".csv"] = buf.Bytes()
+		}
+		for ext, data := range layer {
+			if err := os.WriteFile(base+ext, data, 0644); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing output file: %v\n", err)
+				return exitFailure
+			}
+		}
+		written++
+	}
+	fmt.Printf("Wrote %d layers (%d block states in the legend) to %s\n", written, len(legend.Entries), outDir)
+	return exitOK
+}
@@ -0,0 +1,147 @@
+package slime
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Part is a kind of data stored in a slime chunk, for size breakdowns.
+type Part int
+
+const (
+	// PartSections is the block states of every section, plus the chunk's
+	// coordinates and section count.
+	PartSections Part = iota
+	PartLight
+	PartBiomes
+	PartHeightmaps
+	PartPOI
+	PartTicks // block and fluid ticks
+	PartTileEntities
+	PartEntities
+	PartPDC // the chunk's extra data compound
+
+	NumParts = iota
+)
+
+// PartNames are the names of the parts, indexed by Part.
+var PartNames = [NumParts]string{
+	"sections", "light", "biomes", "heightmaps", "poi", "ticks", "tileEntities", "entities", "pdc",
+}
+
+func (p Part) String() string {
+	return PartNames[p]
+}
+
+// PartSize is the number of bytes a part takes up in a chunk.
+type PartSize struct {
+	Uncompressed int `json:"uncompressed"`
+	// Compressed estimates the part's share of the compressed chunk data:
+	// its bytes compressed on their own, at most their uncompressed size.
+	// Slime files compress all chunks together, so the estimates add up
+	// to more than the actual size.
+	Compressed int `json:"compressed"`
+}
+
+// ChunkParts is the size breakdown of one chunk in a slime file.
+type ChunkParts struct {
+	X, Z  int32
+	Parts [NumParts]PartSize
+
+	raw [NumParts][]byte
+}
+
+// Uncompressed returns the chunk's total uncompressed size.
+func (c *ChunkParts) Uncompressed() int {
+	n := 0
+	for _, p := range c.Parts {
+		n += p.Uncompressed
+	}
+	return n
+}
+
+// Compressed returns the sum of the chunk's compressed part estimates.
+func (c *ChunkParts) Compressed() int {
+	n := 0
+	for _, p := range c.Parts {
+		n += p.Compressed
+	}
+	return n
+}
+
+// InspectSlimeWorld reads a slime file's header and breaks down the size of
+// every chunk by part, in file order.
+func InspectSlimeWorld(data []byte) (*Header, []ChunkParts, error) {
+	var worldVersion uint32
+	header, chunksData, err := readChunkData(bytes.NewReader(data), &worldVersion)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var parts []ChunkParts
+	if _, err := parseChunks(chunksData, header.Flags, header.Version, &parts); err != nil {
+		return nil, nil, fmt.Errorf("parsing chunks: %w", err)
+	}
+
+	encoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer encoder.Close()
+	var buf []byte
+	for i := range parts {
+		c := &parts[i]
+		for p, raw := range c.raw {
+			if len(raw) > 0 {
+				buf = encoder.EncodeAll(raw, buf[:0])
+				c.Parts[p].Compressed = min(len(buf), len(raw))
+			}
+		}
+		c.raw = [NumParts][]byte{}
+	}
+	return header, parts, nil
+}
+
+// partTracker attributes the bytes of one chunk to parts as parseChunk
+// reads them. Its methods do nothing on a nil tracker, so parsing without
+// a breakdown costs nothing.
+type partTracker struct {
+	data  []byte
+	r     *bytes.Reader
+	part  Part
+	start int
+	raw   [NumParts][]byte
+}
+
+func newPartTracker(data []byte, r *bytes.Reader) *partTracker {
+	t := &partTracker{data: data, r: r, part: PartSections}
+	t.start = t.pos()
+	return t
+}
+
+func (t *partTracker) pos() int {
+	return len(t.data) - t.r.Len()
+}
+
+// enter assigns the bytes read since the previous call to the current part
+// and makes p the current part.
+func (t *partTracker) enter(p Part) {
+	if t == nil {
+		return
+	}
+	end := t.pos()
+	t.raw[t.part] = append(t.raw[t.part], t.data[t.start:end]...)
+	t.part, t.start = p, end
+}
+
+// finish closes the current part and returns the chunk's breakdown.
+func (t *partTracker) finish(x, z int32) ChunkParts {
+	t.enter(PartSections)
+	c := ChunkParts{X: x, Z: z, raw: t.raw}
+	for p, raw := range t.raw {
+		c.Parts[p].Uncompressed = len(raw)
+	}
+	return c
+}
@@ -0,0 +1,53 @@
+package slime_test
+
+import (
+	"testing"
+
+	"github.com/emmanuelvlad/slime2schem/internal/testworld"
+	"github.com/emmanuelvlad/slime2schem/slime"
+)
+
+func TestInspectSlimeWorld(t *testing.T) {
+	world := testworld.Overworld()
+	world.Chunks = append(world.Chunks, slime.Chunk{X: 1, Sections: []slime.Section{testworld.Air()}})
+	data, err := slime.WriteSlimeWorld(world)
+	if err != nil {
+		t.Fatal(err)
+	}
+	read, err := slime.ReadSlimeWorld(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, parts, err := slime.InspectSlimeWorld(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Version != slime.SlimeVersion || len(parts) != 2 {
+		t.Fatalf("version %d with %d chunks, want %d with 2", header.Version, len(parts), slime.SlimeVersion)
+	}
+
+	for i, c := range parts {
+		if c.X != read.Chunks[i].X || c.Z != read.Chunks[i].Z {
+			t.Errorf("chunk %d at %d,%d, want %d,%d", i, c.X, c.Z, read.Chunks[i].X, read.Chunks[i].Z)
+		}
+		// The parts cover the chunk's bytes exactly once.
+		if c.Uncompressed() != read.Chunks[i].Size {
+			t.Errorf("chunk %d: parts add up to %d bytes, chunk is %d", i, c.Uncompressed(), read.Chunks[i].Size)
+		}
+		for p, size := range c.Parts {
+			if size.Compressed > size.Uncompressed {
+				t.Errorf("chunk %d %s: compressed %d > uncompressed %d", i, slime.Part(p), size.Compressed, size.Uncompressed)
+			}
+		}
+		if c.Parts[slime.PartPOI].Uncompressed != 0 {
+			t.Errorf("chunk %d: %d bytes of POI in a world without them", i, c.Parts[slime.PartPOI].Uncompressed)
+		}
+	}
+
+	// Only the first chunk has objects.
+	for _, p := range []slime.Part{slime.PartTileEntities, slime.PartEntities} {
+		if full, empty := parts[0].Parts[p].Uncompressed, parts[1].Parts[p].Uncompressed; full <= empty {
+			t.Errorf("%s: %d bytes with objects, %d without", p, full, empty)
+		}
+	}
+}
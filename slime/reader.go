@@ -3,6 +3,7 @@ package slime
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 
@@ -18,21 +19,66 @@ const (
 	FlagPOIChunks  = 1
 	FlagFluidTicks = 2
 	FlagBlockTicks = 4
+
+	// v13 section flags
+	SectionBlockLight = 1
+	SectionSkyLight   = 2
 )
 
 // SlimeWorld represents a parsed slime world.
 type SlimeWorld struct {
 	WorldVersion uint32
 	Chunks       []Chunk
+	// Extra is the world's extra data compound (e.g. its PDC), or nil.
+	Extra map[string]interface{}
+	// MinY is the block Y of the bottom of every chunk's first section.
+	// Slime files do not record it, so ReadSlimeWorld sets it with GuessMinY.
+	MinY int
+	// Header describes the file the world was read from, or is nil if it
+	// was not read from a slime file.
+	Header *Header
+}
+
+// Header holds the format details of a slime file that do not affect the
+// world's contents.
+type Header struct {
+	Version uint8 // slime format version
+	Flags   uint8 // world flags (FlagPOIChunks, ...)
+	// Sizes of the chunk and extra data blocks, before and after zstd
+	// compression.
+	ChunksCompressed   int
+	ChunksUncompressed int
+	ExtraCompressed    int
+	ExtraUncompressed  int
 }
 
 // Chunk represents a single chunk in the slime world.
 type Chunk struct {
-	X            int32
-	Z            int32
-	Sections     []Section
+	X        int32
+	Z        int32
+	Sections []Section
+	// Heightmaps holds the chunk's heightmap long arrays keyed by type
+	// (e.g. MOTION_BLOCKING), or nil if the world has none.
+	Heightmaps   map[string]interface{}
 	TileEntities []map[string]interface{}
 	Entities     []map[string]interface{}
+
+	// Scheduled ticks, present only if the world was saved with the
+	// FlagBlockTicks / FlagFluidTicks flags.
+	BlockTicks []map[string]interface{}
+	FluidTicks []map[string]interface{}
+
+	// Extra is the chunk's extra data compound; Paper stores the chunk's
+	// PDC in it under ChunkBukkitValues.
+	Extra map[string]interface{}
+
+	// Size is the number of bytes the chunk takes in the uncompressed chunk
+	// data, or 0 if it was not read from a slime file.
+	Size int
+
+	// skipped holds the NBT blobs that could not be decoded and were read
+	// as empty, for Validate to report.
+	skipped []Problem
 }
 
 // Section represents a 16x16x16 chunk section.
@@ -40,6 +86,13 @@ type Section struct {
 	BlockPalette []BlockState
 	BlockStates  []int64 // packed block state indices
 	BitsPerBlock int
+	// Biomes is the section's biomes compound (palette and packed data), as
+	// stored in chunk NBT, or nil if the section has none.
+	Biomes map[string]interface{}
+	// BlockLight and SkyLight are 2048-byte nibble arrays, or nil if the
+	// section has no light data.
+	BlockLight []byte
+	SkyLight   []byte
 }
 
 // BlockState represents a block in the palette.
@@ -53,78 +106,118 @@ func ReadSlimeWorld(data []byte) (*SlimeWorld, error) {
 	r := bytes.NewReader(data)
 	world := &SlimeWorld{}
 
-	// Read header
+	header, chunksData, err := readChunkData(r, &world.WorldVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse chunks
+	chunks, err := parseChunks(chunksData, header.Flags, header.Version, nil)
+	if err != nil {
+		return nil, fmt.Errorf("parsing chunks: %w", err)
+	}
+	world.Chunks = chunks
+	world.Header = header
+	world.MinY = GuessMinY(world)
+
+	// Read compressed extra data. Older writers may omit it, and it is not
+	// needed for conversion, so a missing or unreadable block is ignored.
+	var compExtraSize, uncompExtraSize int32
+	if err := binary.Read(r, binary.BigEndian, &compExtraSize); err != nil {
+		return world, nil
+	}
+	if err := binary.Read(r, binary.BigEndian, &uncompExtraSize); err != nil {
+		return world, nil
+	}
+	compExtra := make([]byte, compExtraSize)
+	if _, err := io.ReadFull(r, compExtra); err != nil || compExtraSize == 0 {
+		return world, nil
+	}
+	if extraData, err := decompressZstd(compExtra); err == nil {
+		world.Header.ExtraCompressed = int(compExtraSize)
+		world.Header.ExtraUncompressed = len(extraData)
+		var extra map[string]interface{}
+		if err := nbt.Unmarshal(extraData, &extra); err == nil {
+			world.Extra = extra
+		}
+	}
+
+	return world, nil
+}
+
+// dataVersion1_18 is the data version of Minecraft 1.18, which lowered the
+// bottom of the overworld to -64.
+const dataVersion1_18 = 2860
+
+// GuessMinY returns the likely block Y of the bottom of world's first
+// section: -64 for a world from 1.18 or later with more than 16 sections
+// in some chunk, the height of an overworld, and 0 otherwise (older
+// worlds, the nether and the end).
+func GuessMinY(world *SlimeWorld) int {
+	if world.WorldVersion < dataVersion1_18 {
+		return 0
+	}
+	for i := range world.Chunks {
+		if len(world.Chunks[i].Sections) > 16 {
+			return -64
+		}
+	}
+	return 0
+}
+
+// readChunkData reads the file header and the chunk data block, returning
+// the chunk data uncompressed. It leaves r at the extra data block.
+func readChunkData(r *bytes.Reader, worldVersion *uint32) (*Header, []byte, error) {
 	var magic uint16
 	if err := binary.Read(r, binary.BigEndian, &magic); err != nil {
-		return nil, fmt.Errorf("reading magic: %w", err)
+		return nil, nil, fmt.Errorf("reading magic: %w", err)
 	}
 	if magic != SlimeMagic {
-		return nil, fmt.Errorf("invalid magic: 0x%04X (expected 0x%04X)", magic, SlimeMagic)
+		return nil, nil, fmt.Errorf("invalid magic: 0x%04X (expected 0x%04X)", magic, SlimeMagic)
 	}
 
-	var version uint8
-	if err := binary.Read(r, binary.BigEndian, &version); err != nil {
-		return nil, fmt.Errorf("reading version: %w", err)
+	header := &Header{}
+	if err := binary.Read(r, binary.BigEndian, &header.Version); err != nil {
+		return nil, nil, fmt.Errorf("reading version: %w", err)
 	}
-	if version < SlimeVersionMin || version > SlimeVersionMax {
-		return nil, fmt.Errorf("unsupported slime version: %d (supported: %d-%d)", version, SlimeVersionMin, SlimeVersionMax)
+	if header.Version < SlimeVersionMin || header.Version > SlimeVersionMax {
+		return nil, nil, fmt.Errorf("unsupported slime version: %d (supported: %d-%d)", header.Version, SlimeVersionMin, SlimeVersionMax)
 	}
 
-	if err := binary.Read(r, binary.BigEndian, &world.WorldVersion); err != nil {
-		return nil, fmt.Errorf("reading world version: %w", err)
+	if err := binary.Read(r, binary.BigEndian, worldVersion); err != nil {
+		return nil, nil, fmt.Errorf("reading world version: %w", err)
 	}
 
-	var worldFlags uint8
-	if version >= 0x0D { // v13+ added world flags
-		if err := binary.Read(r, binary.BigEndian, &worldFlags); err != nil {
-			return nil, fmt.Errorf("reading world flags: %w", err)
+	if header.Version >= 0x0D { // v13+ added world flags
+		if err := binary.Read(r, binary.BigEndian, &header.Flags); err != nil {
+			return nil, nil, fmt.Errorf("reading world flags: %w", err)
 		}
 	}
 
 	// Read compressed chunks
 	var compChunksSize, uncompChunksSize int32
 	if err := binary.Read(r, binary.BigEndian, &compChunksSize); err != nil {
-		return nil, fmt.Errorf("reading compressed chunks size: %w", err)
+		return nil, nil, fmt.Errorf("reading compressed chunks size: %w", err)
 	}
 	if err := binary.Read(r, binary.BigEndian, &uncompChunksSize); err != nil {
-		return nil, fmt.Errorf("reading uncompressed chunks size: %w", err)
+		return nil, nil, fmt.Errorf("reading uncompressed chunks size: %w", err)
 	}
 
 	compChunksData := make([]byte, compChunksSize)
 	if _, err := io.ReadFull(r, compChunksData); err != nil {
-		return nil, fmt.Errorf("reading compressed chunks data: %w", err)
+		return nil, nil, fmt.Errorf("reading compressed chunks data: %w", err)
 	}
 
 	chunksData, err := decompressZstd(compChunksData)
 	if err != nil {
-		return nil, fmt.Errorf("decompressing chunks: %w", err)
+		return nil, nil, fmt.Errorf("decompressing chunks: %w", err)
 	}
 	if len(chunksData) != int(uncompChunksSize) {
-		return nil, fmt.Errorf("chunk data size mismatch: got %d, expected %d", len(chunksData), uncompChunksSize)
-	}
-
-	// Parse chunks
-	chunks, err := parseChunks(chunksData, worldFlags, version)
-	if err != nil {
-		return nil, fmt.Errorf("parsing chunks: %w", err)
-	}
-	world.Chunks = chunks
-
-	// Read compressed extra data (skip it, not needed for schematic)
-	var compExtraSize, uncompExtraSize int32
-	if err := binary.Read(r, binary.BigEndian, &compExtraSize); err != nil {
-		// May not exist if at end of file
-		return world, nil
-	}
-	if err := binary.Read(r, binary.BigEndian, &uncompExtraSize); err != nil {
-		return world, nil
-	}
-	// Skip the extra data
-	if _, err := io.CopyN(io.Discard, r, int64(compExtraSize)); err != nil {
-		return world, nil
+		return nil, nil, fmt.Errorf("chunk data size mismatch: got %d, expected %d", len(chunksData), uncompChunksSize)
 	}
-
-	return world, nil
+	header.ChunksCompressed = int(compChunksSize)
+	header.ChunksUncompressed = int(uncompChunksSize)
+	return header, chunksData, nil
 }
 
 func decompressZstd(data []byte) ([]byte, error) {
@@ -136,7 +229,9 @@ func decompressZstd(data []byte) ([]byte, error) {
 	return io.ReadAll(decoder)
 }
 
-func parseChunks(data []byte, worldFlags uint8, version uint8) ([]Chunk, error) {
+// parseChunks parses the uncompressed chunk data. If parts is not nil, the
+// size breakdown of every chunk is appended to it.
+func parseChunks(data []byte, worldFlags uint8, version uint8, parts *[]ChunkParts) ([]Chunk, error) {
 	r := bytes.NewReader(data)
 
 	// Read chunk count (first 4 bytes of chunk data)
@@ -149,19 +244,27 @@ func parseChunks(data []byte, worldFlags uint8, version uint8) ([]Chunk, error)
 
 	for i := int32(0); i < chunkCount; i++ {
 		startPos := int(int64(len(data)) - int64(r.Len()))
-		chunk, err := parseChunk(r, worldFlags, version)
+		var t *partTracker
+		if parts != nil {
+			t = newPartTracker(data, r)
+		}
+		chunk, err := parseChunk(r, worldFlags, version, t)
 		if err != nil {
 			endPos := int(int64(len(data)) - int64(r.Len()))
 			return nil, fmt.Errorf("chunk #%d/%d (x=%d z=%d, started at byte %d, failed at byte %d): %w",
 				i, chunkCount, chunk.X, chunk.Z, startPos, endPos, err)
 		}
+		chunk.Size = int(int64(len(data))-int64(r.Len())) - startPos
 		chunks = append(chunks, chunk)
+		if parts != nil {
+			*parts = append(*parts, t.finish(chunk.X, chunk.Z))
+		}
 	}
 
 	return chunks, nil
 }
 
-func parseChunk(r *bytes.Reader, worldFlags uint8, version uint8) (Chunk, error) {
+func parseChunk(r *bytes.Reader, worldFlags uint8, version uint8, t *partTracker) (Chunk, error) {
 	var chunk Chunk
 
 	// Chunk coordinates
@@ -180,23 +283,26 @@ func parseChunk(r *bytes.Reader, worldFlags uint8, version uint8) (Chunk, error)
 
 	// Parse sections
 	for i := int32(0); i < sectionCount; i++ {
-		section, err := parseSection(r, version)
-		if err != nil {
-			return chunk, fmt.Errorf("parsing section %d: %w", i, err)
+		section, err := parseSection(r, version, t)
+		if err := chunk.skipUndecodable(int(i), fmt.Sprintf("parsing section %d", i), err); err != nil {
+			return chunk, err
 		}
 		chunk.Sections = append(chunk.Sections, section)
 	}
 
-	// Read heightmaps (skip)
-	if err := skipSizedData(r); err != nil {
-		return chunk, fmt.Errorf("skipping heightmaps: %w", err)
+	t.enter(PartHeightmaps)
+	heightmaps, err := readNBTCompound(r)
+	if err := chunk.skipUndecodable(-1, "reading heightmaps", err); err != nil {
+		return chunk, err
 	}
+	chunk.Heightmaps = heightmaps
 
 	// Handle additional flags
 	// Order per doc: POI chunks, then block ticks, then fluid ticks
 
 	// POI chunks (bitmask 1)
 	if worldFlags&FlagPOIChunks != 0 {
+		t.enter(PartPOI)
 		if err := skipSizedData(r); err != nil {
 			return chunk, fmt.Errorf("skipping POI chunks: %w", err)
 		}
@@ -204,43 +310,73 @@ func parseChunk(r *bytes.Reader, worldFlags uint8, version uint8) (Chunk, error)
 
 	// Block ticks (bitmask 4) - note: doc order puts this before fluid ticks
 	if worldFlags&FlagBlockTicks != 0 {
-		if err := skipSizedData(r); err != nil {
-			return chunk, fmt.Errorf("skipping block ticks: %w", err)
+		t.enter(PartTicks)
+		ticks, err := readNBTListSection(r, "block_ticks")
+		if err := chunk.skipUndecodable(-1, "reading block ticks", err); err != nil {
+			return chunk, err
 		}
+		chunk.BlockTicks = ticks
 	}
 
 	// Fluid ticks (bitmask 2)
 	if worldFlags&FlagFluidTicks != 0 {
-		if err := skipSizedData(r); err != nil {
-			return chunk, fmt.Errorf("skipping fluid ticks: %w", err)
+		t.enter(PartTicks)
+		ticks, err := readNBTListSection(r, "fluid_ticks")
+		if err := chunk.skipUndecodable(-1, "reading fluid ticks", err); err != nil {
+			return chunk, err
 		}
+		chunk.FluidTicks = ticks
 	}
 
 	// Tile entities
+	t.enter(PartTileEntities)
 	tileEntities, err := readNBTListSection(r, "tileEntities")
-	if err != nil {
-		return chunk, fmt.Errorf("reading tile entities: %w", err)
+	if err := chunk.skipUndecodable(-1, "reading tile entities", err); err != nil {
+		return chunk, err
 	}
 	chunk.TileEntities = tileEntities
 
 	// Entities
+	t.enter(PartEntities)
 	entities, err := readNBTListSection(r, "entities")
-	if err != nil {
-		return chunk, fmt.Errorf("reading entities: %w", err)
+	if err := chunk.skipUndecodable(-1, "reading entities", err); err != nil {
+		return chunk, err
 	}
 	chunk.Entities = entities
 
 	// Per-chunk extra data / PDC (size-prefixed, added in v12)
-	if err := skipSizedData(r); err != nil {
-		return chunk, fmt.Errorf("skipping chunk extra/PDC data: %w", err)
+	t.enter(PartPDC)
+	extra, err := readNBTCompound(r)
+	if err := chunk.skipUndecodable(-1, "reading chunk extra/PDC data", err); err != nil {
+		return chunk, err
 	}
+	chunk.Extra = extra
 
 	return chunk, nil
 }
 
-func parseSection(r *bytes.Reader, version uint8) (Section, error) {
+// skipUndecodable returns err prefixed with what, except for NBT that could
+// not be decoded: that is recorded for Validate and nil is returned, so a
+// corrupt blob costs its own data rather than the whole world.
+func (c *Chunk) skipUndecodable(section int, what string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, errUndecodable) {
+		c.skipped = append(c.skipped, Problem{
+			Chunk:   [2]int32{c.X, c.Z},
+			Section: section,
+			Message: fmt.Sprintf("%s: %v", what, err),
+		})
+		return nil
+	}
+	return fmt.Errorf("%s: %w", what, err)
+}
+
+func parseSection(r *bytes.Reader, version uint8, t *partTracker) (Section, error) {
 	var section Section
 
+	t.enter(PartLight)
 	if version >= 0x0D {
 		// v13+: single flags bitmask byte (1=blockLight, 2=skyLight)
 		var flags uint8
@@ -249,14 +385,16 @@ func parseSection(r *bytes.Reader, version uint8) (Section, error) {
 		}
 
 		// v13 order: skyLight first, then blockLight
-		if flags&2 != 0 {
-			if _, err := r.Seek(2048, io.SeekCurrent); err != nil {
-				return section, fmt.Errorf("skipping sky light: %w", err)
+		if flags&SectionSkyLight != 0 {
+			section.SkyLight = make([]byte, 2048)
+			if _, err := io.ReadFull(r, section.SkyLight); err != nil {
+				return section, fmt.Errorf("reading sky light: %w", err)
 			}
 		}
-		if flags&1 != 0 {
-			if _, err := r.Seek(2048, io.SeekCurrent); err != nil {
-				return section, fmt.Errorf("skipping block light: %w", err)
+		if flags&SectionBlockLight != 0 {
+			section.BlockLight = make([]byte, 2048)
+			if _, err := io.ReadFull(r, section.BlockLight); err != nil {
+				return section, fmt.Errorf("reading block light: %w", err)
 			}
 		}
 	} else {
@@ -267,8 +405,9 @@ func parseSection(r *bytes.Reader, version uint8) (Section, error) {
 			return section, fmt.Errorf("reading block light flag: %w", err)
 		}
 		if hasBlockLight != 0 {
-			if _, err := r.Seek(2048, io.SeekCurrent); err != nil {
-				return section, fmt.Errorf("skipping block light: %w", err)
+			section.BlockLight = make([]byte, 2048)
+			if _, err := io.ReadFull(r, section.BlockLight); err != nil {
+				return section, fmt.Errorf("reading block light: %w", err)
 			}
 		}
 
@@ -277,13 +416,15 @@ func parseSection(r *bytes.Reader, version uint8) (Section, error) {
 			return section, fmt.Errorf("reading sky light flag: %w", err)
 		}
 		if hasSkyLight != 0 {
-			if _, err := r.Seek(2048, io.SeekCurrent); err != nil {
-				return section, fmt.Errorf("skipping sky light: %w", err)
+			section.SkyLight = make([]byte, 2048)
+			if _, err := io.ReadFull(r, section.SkyLight); err != nil {
+				return section, fmt.Errorf("reading sky light: %w", err)
 			}
 		}
 	}
 
 	// Block states NBT
+	t.enter(PartSections)
 	var blockStatesSize int32
 	if err := binary.Read(r, binary.BigEndian, &blockStatesSize); err != nil {
 		return section, fmt.Errorf("reading block states size: %w", err)
@@ -304,16 +445,12 @@ func parseSection(r *bytes.Reader, version uint8) (Section, error) {
 		section.BitsPerBlock = bitsPerBlock
 	}
 
-	// Biomes NBT (skip)
-	var biomesSize int32
-	if err := binary.Read(r, binary.BigEndian, &biomesSize); err != nil {
-		return section, fmt.Errorf("reading biomes size: %w", err)
-	}
-	if biomesSize > 0 {
-		if _, err := r.Seek(int64(biomesSize), io.SeekCurrent); err != nil {
-			return section, fmt.Errorf("skipping biomes: %w", err)
-		}
+	t.enter(PartBiomes)
+	biomes, err := readNBTCompound(r)
+	if err != nil {
+		return section, fmt.Errorf("reading biomes: %w", err)
 	}
+	section.Biomes = biomes
 
 	return section, nil
 }
@@ -344,21 +481,30 @@ func parseBlockStatesNBT(data []byte) ([]BlockState, []int64, int, error) {
 		}
 	}
 
-	// Calculate bits per block
-	bitsPerBlock := 4 // minimum
-	paletteSize := len(palette)
-	if paletteSize > 0 {
-		bits := 0
-		for (1 << bits) < paletteSize {
-			bits++
-		}
-		if bits < 4 {
-			bits = 4
-		}
-		bitsPerBlock = bits
+	return palette, blockStates.Data, bitsPerBlockFor(len(palette)), nil
+}
+
+// NewSection builds a section from a block_states palette and its packed
+// data, as stored in chunk NBT.
+func NewSection(palette []BlockState, data []int64) Section {
+	return Section{
+		BlockPalette: palette,
+		BlockStates:  data,
+		BitsPerBlock: bitsPerBlockFor(len(palette)),
 	}
+}
 
-	return palette, blockStates.Data, bitsPerBlock, nil
+// bitsPerBlockFor returns the packed entry size for a palette: enough bits
+// to index every entry, and at least 4.
+func bitsPerBlockFor(paletteSize int) int {
+	bits := 0
+	for (1 << bits) < paletteSize {
+		bits++
+	}
+	if bits < 4 {
+		bits = 4
+	}
+	return bits
 }
 
 func skipSizedData(r *bytes.Reader) error {
@@ -374,6 +520,40 @@ func skipSizedData(r *bytes.Reader) error {
 	return nil
 }
 
+// errUndecodable is returned, wrapped, for size-prefixed data that is not
+// NBT. The data has been consumed, so reading can go on past it.
+var errUndecodable = errors.New("undecodable NBT")
+
+// readNBTCompound reads a size-prefixed NBT compound. Empty data yields a
+// nil map; data that is not NBT yields a nil map and errUndecodable.
+func readNBTCompound(r *bytes.Reader) (map[string]interface{}, error) {
+	var size int32
+	if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+		return nil, err
+	}
+	if size <= 0 {
+		return nil, nil
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	if isEmptyNBT(data) {
+		return nil, nil
+	}
+	var compound map[string]interface{}
+	if err := nbt.Unmarshal(data, &compound); err != nil {
+		return nil, fmt.Errorf("%w: %v", errUndecodable, err)
+	}
+	return compound, nil
+}
+
+// isEmptyNBT reports whether data holds no tag at all: just TAG_End, as some
+// writers store an absent compound.
+func isEmptyNBT(data []byte) bool {
+	return len(data) == 1 && data[0] == 0
+}
+
 func readNBTListSection(r *bytes.Reader, listName string) ([]map[string]interface{}, error) {
 	var size int32
 	if err := binary.Read(r, binary.BigEndian, &size); err != nil {
@@ -389,17 +569,26 @@ func readNBTListSection(r *bytes.Reader, listName string) ([]map[string]interfac
 		return nil, err
 	}
 
+	if isEmptyNBT(nbtData) {
+		return nil, nil
+	}
+
 	// The NBT contains a compound with a list tag named listName
 	var container map[string]interface{}
 	if err := nbt.Unmarshal(nbtData, &container); err != nil {
-		// If standard unmarshal fails, the data might be empty or malformed
-		// Just skip it
-		return nil, nil
+		return nil, fmt.Errorf("%w: %v", errUndecodable, err)
 	}
 
 	listRaw, ok := container[listName]
 	if !ok {
-		return nil, nil
+		// Fall back to the only list in the compound, in case the writer
+		// used a different name for it.
+		if len(container) != 1 {
+			return nil, nil
+		}
+		for _, v := range container {
+			listRaw = v
+		}
 	}
 
 	// The list should be a slice of interface{}
@@ -424,11 +613,15 @@ func (s *Section) GetBlockAt(x, y, z int) BlockState {
 	if len(s.BlockPalette) == 0 {
 		return BlockState{Name: "minecraft:air"}
 	}
-	if len(s.BlockPalette) == 1 {
-		return s.BlockPalette[0]
-	}
-	if len(s.BlockStates) == 0 {
-		return s.BlockPalette[0]
+	return s.BlockPalette[s.PaletteIndexAt(x, y, z)]
+}
+
+// PaletteIndexAt returns the index into BlockPalette of the block at a
+// specific position within a section. x, y, z are local coordinates (0-15).
+// Out-of-range data resolves to index 0.
+func (s *Section) PaletteIndexAt(x, y, z int) int {
+	if len(s.BlockPalette) <= 1 || len(s.BlockStates) == 0 {
+		return 0
 	}
 
 	// Minecraft packed format: blocks are indexed as y*16*16 + z*16 + x
@@ -443,15 +636,15 @@ func (s *Section) GetBlockAt(x, y, z int) BlockState {
 	bitOffset := (blockIndex % blocksPerLong) * bitsPerBlock
 
 	if longIndex >= len(s.BlockStates) {
-		return s.BlockPalette[0]
+		return 0
 	}
 
 	mask := int64((1 << bitsPerBlock) - 1)
 	paletteIndex := int((s.BlockStates[longIndex] >> bitOffset) & mask)
 
 	if paletteIndex >= len(s.BlockPalette) {
-		return s.BlockPalette[0]
+		return 0
 	}
 
-	return s.BlockPalette[paletteIndex]
+	return paletteIndex
 }
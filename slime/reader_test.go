@@ -0,0 +1,53 @@
+package slime
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+func TestGuessMinY(t *testing.T) {
+	overworld := make([]Section, 24)
+	for _, tt := range []struct {
+		version  uint32
+		sections []Section
+		want     int
+	}{
+		{3953, overworld, -64},
+		{3953, overworld[:16], 0}, // nether or end
+		{2730, overworld[:16], 0}, // 1.17
+	} {
+		world := &SlimeWorld{WorldVersion: tt.version, Chunks: []Chunk{{Sections: tt.sections}}}
+		if got := GuessMinY(world); got != tt.want {
+			t.Errorf("GuessMinY(version %d, %d sections) = %d, want %d", tt.version, len(tt.sections), got, tt.want)
+		}
+	}
+}
+
+func TestUndecodableNBTIsSkipped(t *testing.T) {
+	// A chunk at 2,3 with no sections, whose heightmaps are not NBT (a
+	// compound with a truncated name), followed by empty entity lists.
+	var buf bytes.Buffer
+	for _, v := range []int32{2, 3, 0, 3} {
+		binary.Write(&buf, binary.BigEndian, v)
+	}
+	buf.Write([]byte{0x0A, 0xFF, 0xFF})
+	binary.Write(&buf, binary.BigEndian, int32(1))
+	buf.WriteByte(0) // TAG_End alone: no tile entities
+	binary.Write(&buf, binary.BigEndian, int32(0))
+	binary.Write(&buf, binary.BigEndian, int32(0))
+
+	chunk, err := parseChunk(bytes.NewReader(buf.Bytes()), 0, SlimeVersion, nil)
+	if err != nil {
+		t.Fatalf("parseChunk: %v", err)
+	}
+	if chunk.Heightmaps != nil || chunk.TileEntities != nil {
+		t.Errorf("heightmaps = %v, tile entities = %v; want nil", chunk.Heightmaps, chunk.TileEntities)
+	}
+
+	problems := Validate(&SlimeWorld{Chunks: []Chunk{chunk}})
+	if len(problems) != 1 || problems[0].Chunk != [2]int32{2, 3} || !strings.Contains(problems[0].Message, "reading heightmaps: undecodable NBT") {
+		t.Errorf("problems = %v, want the skipped heightmaps", problems)
+	}
+}
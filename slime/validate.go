@@ -0,0 +1,150 @@
+package slime
+
+import (
+	"fmt"
+	"math"
+)
+
+// Problem is an inconsistency in a world found by Validate.
+type Problem struct {
+	Chunk [2]int32 `json:"chunk"`
+	// Section is the index of the section concerned, or -1 if the problem
+	// is not about a section.
+	Section int    `json:"section"`
+	Message string `json:"message"`
+}
+
+func (p Problem) String() string {
+	if p.Section < 0 {
+		return fmt.Sprintf("chunk %d,%d: %s", p.Chunk[0], p.Chunk[1], p.Message)
+	}
+	return fmt.Sprintf("chunk %d,%d section %d: %s", p.Chunk[0], p.Chunk[1], p.Section, p.Message)
+}
+
+// Validate checks a world for data that readers tolerate but that is
+// likely to be lost or misplaced on conversion: duplicate chunks, block
+// data that does not match its palette, malformed light arrays, entities
+// or block entities without an id or outside their chunk, and NBT that the
+// reader could not decode and skipped.
+func Validate(world *SlimeWorld) []Problem {
+	var problems []Problem
+	seen := make(map[[2]int32]bool, len(world.Chunks))
+	for i := range world.Chunks {
+		c := &world.Chunks[i]
+		pos := [2]int32{c.X, c.Z}
+		report := func(section int, format string, args ...interface{}) {
+			problems = append(problems, Problem{Chunk: pos, Section: section, Message: fmt.Sprintf(format, args...)})
+		}
+
+		if seen[pos] {
+			report(-1, "duplicate chunk")
+		}
+		seen[pos] = true
+		problems = append(problems, c.skipped...)
+
+		for j := range c.Sections {
+			for _, msg := range validateSection(&c.Sections[j]) {
+				report(j, "%s", msg)
+			}
+		}
+
+		for _, te := range c.TileEntities {
+			id, _ := te["id"].(string)
+			if id == "" {
+				id = "block entity"
+				report(-1, "block entity without an id")
+			}
+			x, xOk := intTag(te, "x")
+			_, yOk := intTag(te, "y")
+			z, zOk := intTag(te, "z")
+			switch {
+			case !xOk || !yOk || !zOk:
+				report(-1, "%s has no position", id)
+			case int32(x>>4) != c.X || int32(z>>4) != c.Z:
+				report(-1, "%s at %d,%d is outside the chunk", id, x, z)
+			}
+		}
+
+		for _, e := range c.Entities {
+			id, _ := e["id"].(string)
+			if id == "" {
+				id = "entity"
+				report(-1, "entity without an id")
+			}
+			p, ok := e["Pos"].([]interface{})
+			if !ok || len(p) != 3 {
+				report(-1, "%s has no position", id)
+				continue
+			}
+			x, xOk := p[0].(float64)
+			z, zOk := p[2].(float64)
+			switch {
+			case !xOk || !zOk || math.IsNaN(x) || math.IsNaN(z):
+				report(-1, "%s has an invalid position", id)
+			case int32(math.Floor(x/16)) != c.X || int32(math.Floor(z/16)) != c.Z:
+				report(-1, "%s at %.1f,%.1f is outside the chunk", id, x, z)
+			}
+		}
+	}
+	return problems
+}
+
+func validateSection(s *Section) []string {
+	var msgs []string
+	if len(s.BlockLight) != 0 && len(s.BlockLight) != 2048 {
+		msgs = append(msgs, fmt.Sprintf("block light is %d bytes, expected 2048", len(s.BlockLight)))
+	}
+	if len(s.SkyLight) != 0 && len(s.SkyLight) != 2048 {
+		msgs = append(msgs, fmt.Sprintf("sky light is %d bytes, expected 2048", len(s.SkyLight)))
+	}
+	for i, bs := range s.BlockPalette {
+		if bs.Name == "" {
+			msgs = append(msgs, fmt.Sprintf("palette entry %d has no name", i))
+		}
+	}
+
+	switch {
+	case len(s.BlockPalette) == 0:
+		if len(s.BlockStates) > 0 {
+			msgs = append(msgs, "block data without a palette")
+		}
+		return msgs
+	case len(s.BlockPalette) == 1:
+		return msgs
+	}
+
+	perLong := 64 / s.BitsPerBlock
+	if want := (4096 + perLong - 1) / perLong; len(s.BlockStates) != want {
+		msgs = append(msgs, fmt.Sprintf("block data has %d longs, expected %d for %d palette entries",
+			len(s.BlockStates), want, len(s.BlockPalette)))
+		return msgs
+	}
+	mask := int64(1)<<s.BitsPerBlock - 1
+	bad := 0
+	for i := 0; i < 4096; i++ {
+		idx := (s.BlockStates[i/perLong] >> ((i % perLong) * s.BitsPerBlock)) & mask
+		if int(idx) >= len(s.BlockPalette) {
+			bad++
+		}
+	}
+	if bad > 0 {
+		msgs = append(msgs, fmt.Sprintf("%d blocks use palette indices past the palette's %d entries (read as %s)",
+			bad, len(s.BlockPalette), s.BlockPalette[0].Name))
+	}
+	return msgs
+}
+
+// intTag reads an integer NBT tag of any width.
+func intTag(m map[string]interface{}, key string) (int, bool) {
+	switch v := m[key].(type) {
+	case int8:
+		return int(v), true
+	case int16:
+		return int(v), true
+	case int32:
+		return int(v), true
+	case int64:
+		return int(v), true
+	}
+	return 0, false
+}
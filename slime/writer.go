@@ -0,0 +1,176 @@
+package slime
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/emmanuelvlad/slime2schem/internal/nbtenc"
+)
+
+// SlimeVersion is the slime format version written by WriteSlimeWorld.
+const SlimeVersion = SlimeVersionMax
+
+// WriteSlimeWorld encodes a world in slime format v13. Block and fluid
+// ticks are stored (and their world flags set) only if some chunk has
+// them; POI data is never written, the server rebuilds it.
+func WriteSlimeWorld(world *SlimeWorld) ([]byte, error) {
+	var flags uint8
+	for i := range world.Chunks {
+		if len(world.Chunks[i].BlockTicks) > 0 {
+			flags |= FlagBlockTicks
+		}
+		if len(world.Chunks[i].FluidTicks) > 0 {
+			flags |= FlagFluidTicks
+		}
+	}
+
+	var chunks bytes.Buffer
+	binary.Write(&chunks, binary.BigEndian, int32(len(world.Chunks)))
+	for i := range world.Chunks {
+		if err := writeChunk(&chunks, &world.Chunks[i], flags); err != nil {
+			c := &world.Chunks[i]
+			return nil, fmt.Errorf("chunk %d,%d: %w", c.X, c.Z, err)
+		}
+	}
+
+	extra, err := nbtenc.Marshal(orEmpty(world.Extra))
+	if err != nil {
+		return nil, fmt.Errorf("encoding world extra data: %w", err)
+	}
+
+	encoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer encoder.Close()
+
+	var out bytes.Buffer
+	binary.Write(&out, binary.BigEndian, uint16(SlimeMagic))
+	out.WriteByte(SlimeVersion)
+	binary.Write(&out, binary.BigEndian, world.WorldVersion)
+	out.WriteByte(flags)
+	writeCompressed(&out, encoder, chunks.Bytes())
+	writeCompressed(&out, encoder, extra)
+	return out.Bytes(), nil
+}
+
+// writeCompressed writes a zstd block prefixed with its compressed and
+// uncompressed sizes.
+func writeCompressed(out *bytes.Buffer, encoder *zstd.Encoder, data []byte) {
+	compressed := encoder.EncodeAll(data, nil)
+	binary.Write(out, binary.BigEndian, int32(len(compressed)))
+	binary.Write(out, binary.BigEndian, int32(len(data)))
+	out.Write(compressed)
+}
+
+func writeChunk(w *bytes.Buffer, c *Chunk, flags uint8) error {
+	binary.Write(w, binary.BigEndian, c.X)
+	binary.Write(w, binary.BigEndian, c.Z)
+	binary.Write(w, binary.BigEndian, int32(len(c.Sections)))
+	for i := range c.Sections {
+		if err := writeSection(w, &c.Sections[i]); err != nil {
+			return fmt.Errorf("section %d: %w", i, err)
+		}
+	}
+
+	if err := writeNBT(w, orEmpty(c.Heightmaps)); err != nil {
+		return fmt.Errorf("heightmaps: %w", err)
+	}
+	// Same order as parseChunk: block ticks, then fluid ticks.
+	if flags&FlagBlockTicks != 0 {
+		if err := writeNBTList(w, "block_ticks", c.BlockTicks); err != nil {
+			return fmt.Errorf("block ticks: %w", err)
+		}
+	}
+	if flags&FlagFluidTicks != 0 {
+		if err := writeNBTList(w, "fluid_ticks", c.FluidTicks); err != nil {
+			return fmt.Errorf("fluid ticks: %w", err)
+		}
+	}
+	if err := writeNBTList(w, "tileEntities", c.TileEntities); err != nil {
+		return fmt.Errorf("tile entities: %w", err)
+	}
+	if err := writeNBTList(w, "entities", c.Entities); err != nil {
+		return fmt.Errorf("entities: %w", err)
+	}
+	if err := writeNBT(w, orEmpty(c.Extra)); err != nil {
+		return fmt.Errorf("extra data: %w", err)
+	}
+	return nil
+}
+
+func writeSection(w *bytes.Buffer, s *Section) error {
+	var flags uint8
+	if len(s.BlockLight) == 2048 {
+		flags |= SectionBlockLight
+	}
+	if len(s.SkyLight) == 2048 {
+		flags |= SectionSkyLight
+	}
+	w.WriteByte(flags)
+	if flags&SectionSkyLight != 0 {
+		w.Write(s.SkyLight)
+	}
+	if flags&SectionBlockLight != 0 {
+		w.Write(s.BlockLight)
+	}
+
+	palette := make([]interface{}, 0, len(s.BlockPalette))
+	for _, bs := range s.BlockPalette {
+		entry := map[string]interface{}{"Name": bs.Name}
+		if len(bs.Properties) > 0 {
+			entry["Properties"] = bs.Properties
+		}
+		palette = append(palette, entry)
+	}
+	if len(palette) == 0 {
+		palette = append(palette, map[string]interface{}{"Name": "minecraft:air"})
+	}
+	blockStates := map[string]interface{}{"palette": palette}
+	if len(palette) > 1 && len(s.BlockStates) > 0 {
+		blockStates["data"] = s.BlockStates
+	}
+	if err := writeNBT(w, blockStates); err != nil {
+		return fmt.Errorf("block states: %w", err)
+	}
+
+	biomes := s.Biomes
+	if len(biomes) == 0 {
+		biomes = map[string]interface{}{"palette": []interface{}{"minecraft:plains"}}
+	}
+	if err := writeNBT(w, biomes); err != nil {
+		return fmt.Errorf("biomes: %w", err)
+	}
+	return nil
+}
+
+// writeNBT writes a size-prefixed NBT compound.
+func writeNBT(w *bytes.Buffer, compound map[string]interface{}) error {
+	data, err := nbtenc.Marshal(compound)
+	if err != nil {
+		return err
+	}
+	binary.Write(w, binary.BigEndian, int32(len(data)))
+	w.Write(data)
+	return nil
+}
+
+// writeNBTList writes a size-prefixed compound holding list under name, the
+// layout readNBTListSection expects.
+func writeNBTList(w *bytes.Buffer, name string, list []map[string]interface{}) error {
+	items := make([]interface{}, len(list))
+	for i, m := range list {
+		items[i] = m
+	}
+	return writeNBT(w, map[string]interface{}{name: items})
+}
+
+func orEmpty(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return map[string]interface{}{}
+	}
+	return m
+}
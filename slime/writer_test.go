@@ -0,0 +1,92 @@
+package slime_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/emmanuelvlad/slime2schem/internal/testworld"
+	"github.com/emmanuelvlad/slime2schem/slime"
+)
+
+// testWorld returns the testworld overworld with the data only slime
+// stores: light, biomes, heightmaps, ticks and extra compounds.
+func testWorld() *slime.SlimeWorld {
+	w := testworld.Overworld()
+	w.Extra = map[string]interface{}{"owner": "test"}
+	c := &w.Chunks[0]
+	s := &c.Sections[1]
+	s.BlockLight = bytes.Repeat([]byte{0xF0}, 2048)
+	s.SkyLight = s.BlockLight
+	s.Biomes = map[string]interface{}{"palette": []interface{}{"minecraft:forest"}}
+	c.Heightmaps = map[string]interface{}{"MOTION_BLOCKING": []int64{1, 2, 3}}
+	c.BlockTicks = []map[string]interface{}{{"i": "minecraft:stone", "x": int32(0), "y": int32(-48), "z": int32(0), "t": int32(4), "p": int32(0)}}
+	c.Extra = map[string]interface{}{"ChunkBukkitValues": map[string]interface{}{"plugin:key": int8(1)}}
+	w.Chunks = append(w.Chunks, slime.Chunk{X: -1, Z: 2})
+	return w
+}
+
+func TestWriteReadRoundTrip(t *testing.T) {
+	want := testWorld()
+	data, err := slime.WriteSlimeWorld(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := slime.ReadSlimeWorld(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Header.Version != slime.SlimeVersion || got.Header.Flags != slime.FlagBlockTicks {
+		t.Errorf("header = %+v, want version %d and flags %d", got.Header, slime.SlimeVersion, slime.FlagBlockTicks)
+	}
+	if got.WorldVersion != want.WorldVersion {
+		t.Errorf("world version = %d, want %d", got.WorldVersion, want.WorldVersion)
+	}
+	if !reflect.DeepEqual(got.Extra, want.Extra) {
+		t.Errorf("extra = %v, want %v", got.Extra, want.Extra)
+	}
+	if len(got.Chunks) != len(want.Chunks) {
+		t.Fatalf("%d chunks, want %d", len(got.Chunks), len(want.Chunks))
+	}
+
+	g, w := got.Chunks[0], want.Chunks[0]
+	if g.X != w.X || g.Z != w.Z {
+		t.Errorf("chunk at %d,%d, want %d,%d", g.X, g.Z, w.X, w.Z)
+	}
+	if len(g.Sections) != len(w.Sections) {
+		t.Fatalf("%d sections, want %d", len(g.Sections), len(w.Sections))
+	}
+	gs, ws := g.Sections[1], w.Sections[1]
+	if !reflect.DeepEqual(gs.BlockPalette, ws.BlockPalette) {
+		t.Errorf("palette = %v, want %v", gs.BlockPalette, ws.BlockPalette)
+	}
+	for _, pos := range [][3]int{{0, 0, 0}, {1, 0, 0}, {15, 15, 15}} {
+		if got, want := gs.GetBlockAt(pos[0], pos[1], pos[2]), ws.GetBlockAt(pos[0], pos[1], pos[2]); got.Name != want.Name {
+			t.Errorf("block at %v = %s, want %s", pos, got.Name, want.Name)
+		}
+	}
+	if !bytes.Equal(gs.BlockLight, ws.BlockLight) || !bytes.Equal(gs.SkyLight, ws.SkyLight) {
+		t.Error("light data differs")
+	}
+	if g.Sections[0].BlockLight != nil {
+		t.Error("section without light read back with light")
+	}
+	for name, pair := range map[string][2]interface{}{
+		"biomes":        {gs.Biomes, ws.Biomes},
+		"heightmaps":    {g.Heightmaps, w.Heightmaps},
+		"tile entities": {g.TileEntities, w.TileEntities},
+		"entities":      {g.Entities, w.Entities},
+		"block ticks":   {g.BlockTicks, w.BlockTicks},
+		"extra":         {g.Extra, w.Extra},
+	} {
+		if !reflect.DeepEqual(pair[0], pair[1]) {
+			t.Errorf("%s = %v, want %v", name, pair[0], pair[1])
+		}
+	}
+
+	empty := got.Chunks[1]
+	if len(empty.Sections) != 0 || len(empty.TileEntities) != 0 || len(empty.Entities) != 0 || len(empty.BlockTicks) != 0 {
+		t.Errorf("empty chunk read back as %+v", empty)
+	}
+}
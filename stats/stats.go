@@ -0,0 +1,183 @@
+// Package stats counts blocks, entities and block entities in a slime world
+// or in the schematic converted from it, e.g. to estimate materials or track
+// content size.
+package stats
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/emmanuelvlad/slime2schem/schematic"
+	"github.com/emmanuelvlad/slime2schem/slime"
+)
+
+// Count is a single named count.
+type Count struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// Stats is a bill of materials. Every list is sorted by descending count,
+// then by name.
+type Stats struct {
+	TotalBlocks   int     `json:"totalBlocks"`
+	Blocks        []Count `json:"blocks"`      // per block name, e.g. minecraft:oak_stairs
+	BlockStates   []Count `json:"blockStates"` // per full block state
+	Entities      []Count `json:"entities"`
+	BlockEntities []Count `json:"blockEntities"`
+}
+
+// FromWorld counts the contents of a slime world. Air is not counted.
+func FromWorld(world *slime.SlimeWorld) *Stats {
+	states := make(map[string]int)
+	entities := make(map[string]int)
+	blockEntities := make(map[string]int)
+
+	for _, chunk := range world.Chunks {
+		for i := range chunk.Sections {
+			countSection(&chunk.Sections[i], states)
+		}
+		for _, te := range chunk.TileEntities {
+			blockEntities[objectId(te)]++
+		}
+		for _, e := range chunk.Entities {
+			entities[objectId(e)]++
+		}
+	}
+
+	return build(states, entities, blockEntities)
+}
+
+// FromSchematic counts the contents of a schematic. Air is not counted.
+// It must be called before Save, which releases the block data.
+func FromSchematic(s *schematic.Schematic) *Stats {
+	entities := make(map[string]int)
+	blockEntities := make(map[string]int)
+	for _, be := range s.BlockEntities {
+		blockEntities[be.Id]++
+	}
+	for _, e := range s.Entities {
+		entities[e.Id]++
+	}
+	return build(s.BlockCounts(), entities, blockEntities)
+}
+
+// countSection adds the blocks of one section to states, counting palette
+// indices first so each block state string is built once per section.
+func countSection(section *slime.Section, states map[string]int) {
+	if len(section.BlockPalette) == 0 {
+		return
+	}
+	perIndex := make([]int, len(section.BlockPalette))
+	for y := 0; y < 16; y++ {
+		for z := 0; z < 16; z++ {
+			for x := 0; x < 16; x++ {
+				perIndex[section.PaletteIndexAt(x, y, z)]++
+			}
+		}
+	}
+	for i, n := range perIndex {
+		bs := section.BlockPalette[i]
+		if n == 0 || isAir(bs.Name) {
+			continue
+		}
+		states[schematic.BlockStateString(bs.Name, bs.Properties)] += n
+	}
+}
+
+func build(states, entities, blockEntities map[string]int) *Stats {
+	names := make(map[string]int)
+	total := 0
+	for state, n := range states {
+		name, _, _ := strings.Cut(state, "[")
+		names[name] += n
+		total += n
+	}
+	return &Stats{
+		TotalBlocks:   total,
+		Blocks:        sorted(names),
+		BlockStates:   sorted(states),
+		Entities:      sorted(entities),
+		BlockEntities: sorted(blockEntities),
+	}
+}
+
+func sorted(m map[string]int) []Count {
+	out := make([]Count, 0, len(m))
+	for name, n := range m {
+		out = append(out, Count{Name: name, Count: n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+func isAir(name string) bool {
+	return name == "minecraft:air" || name == "minecraft:cave_air" || name == "minecraft:void_air"
+}
+
+func objectId(m map[string]interface{}) string {
+	id, _ := m["id"].(string)
+	if id == "" {
+		id, _ = m["Id"].(string)
+	}
+	if id == "" {
+		return "(unknown)"
+	}
+	return id
+}
+
+// WriteTable writes the statistics as aligned plain-text tables.
+func (s *Stats) WriteTable(w io.Writer) error {
+	width := len(strconv.Itoa(s.TotalBlocks))
+	if _, err := fmt.Fprintf(w, "Total blocks: %d\n", s.TotalBlocks); err != nil {
+		return err
+	}
+	for _, section := range s.sections() {
+		if _, err := fmt.Fprintf(w, "\n%s (%d)\n", section.title, len(section.counts)); err != nil {
+			return err
+		}
+		for _, c := range section.counts {
+			if _, err := fmt.Fprintf(w, "  %*d  %s\n", width, c.Count, c.Name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WriteCSV writes the statistics as CSV with a category,name,count header.
+func (s *Stats) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"category", "name", "count"})
+	for _, section := range s.sections() {
+		for _, c := range section.counts {
+			cw.Write([]string{section.category, c.Name, strconv.Itoa(c.Count)})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+type statsSection struct {
+	title    string
+	category string
+	counts   []Count
+}
+
+func (s *Stats) sections() []statsSection {
+	return []statsSection{
+		{"Blocks", "block", s.Blocks},
+		{"Block states", "block_state", s.BlockStates},
+		{"Entities", "entity", s.Entities},
+		{"Block entities", "block_entity", s.BlockEntities},
+	}
+}
@@ -0,0 +1,82 @@
+package stats
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/emmanuelvlad/slime2schem/converter"
+	"github.com/emmanuelvlad/slime2schem/internal/testworld"
+	"github.com/emmanuelvlad/slime2schem/slime"
+)
+
+func testWorld() *slime.SlimeWorld {
+	blocks := make(map[[3]int]string)
+	for z := 0; z < 16; z++ {
+		for x := 0; x < 16; x++ {
+			blocks[[3]int{x, 0, z}] = "minecraft:stone"
+		}
+	}
+	blocks[[3]int{0, 1, 0}] = "minecraft:oak_stairs[facing=east]"
+	blocks[[3]int{1, 1, 0}] = "minecraft:oak_stairs[facing=west]"
+	blocks[[3]int{2, 1, 0}] = "minecraft:oak_stairs[facing=east]"
+	blocks[[3]int{3, 1, 0}] = "minecraft:cave_air"
+
+	w := testworld.Overworld()
+	w.Chunks[0].Sections[1] = testworld.Section("minecraft:air", blocks)
+	w.Chunks = append(w.Chunks, slime.Chunk{
+		X:        1,
+		Sections: []slime.Section{testworld.Air(), testworld.Floor("minecraft:stone")},
+		Entities: []map[string]interface{}{testworld.Pig(20.5, -47, 2.5), testworld.Pig(21.5, -47, 2.5), {"Pos": []interface{}{22.5, -47.0, 2.5}}},
+	})
+	return w
+}
+
+func TestFromWorld(t *testing.T) {
+	s := FromWorld(testWorld())
+	want := &Stats{
+		TotalBlocks:   515,
+		Blocks:        []Count{{"minecraft:stone", 512}, {"minecraft:oak_stairs", 3}},
+		BlockStates:   []Count{{"minecraft:stone", 512}, {"minecraft:oak_stairs[facing=east]", 2}, {"minecraft:oak_stairs[facing=west]", 1}},
+		Entities:      []Count{{"minecraft:pig", 3}, {"(unknown)", 1}},
+		BlockEntities: []Count{{"minecraft:chest", 1}},
+	}
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("FromWorld = %+v, want %+v", s, want)
+	}
+}
+
+func TestFromSchematicMatchesWorld(t *testing.T) {
+	world := testWorld()
+	// The entity without an id is dropped by the conversion.
+	world.Chunks[1].Entities = world.Chunks[1].Entities[:2]
+	result, err := converter.Convert(world)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := FromSchematic(result.Schematic), FromWorld(world); !reflect.DeepEqual(got, want) {
+		t.Errorf("FromSchematic = %+v, want %+v", got, want)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var b strings.Builder
+	if err := FromWorld(testWorld()).WriteCSV(&b); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	want := []string{
+		"category,name,count",
+		"block,minecraft:stone,512",
+		"block,minecraft:oak_stairs,3",
+		"block_state,minecraft:stone,512",
+		"block_state,minecraft:oak_stairs[facing=east],2",
+		"block_state,minecraft:oak_stairs[facing=west],1",
+		"entity,minecraft:pig,3",
+		"entity,(unknown),1",
+		"block_entity,minecraft:chest,1",
+	}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("CSV =\n%s\nwant\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+}
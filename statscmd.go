@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/emmanuelvlad/slime2schem/converter"
+	"github.com/emmanuelvlad/slime2schem/stats"
+)
+
+// runStats implements "slime2schem stats".
+func runStats(args []string) int {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	format := fs.String("format", "table", "Output format: table, csv or json")
+	converted := fs.Bool("converted", false, "Count the converted schematic instead of the raw world (excludes dropped entities)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: slime2schem stats [-format table|csv|json] [-converted] <world.slime>\n")
+		fmt.Fprintf(os.Stderr, "\nCounts blocks, block states, entities and block entities in a world, or with\n")
+		fmt.Fprintf(os.Stderr, "-converted in the schematic it converts to.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+	switch *format {
+	case "table", "csv", "json":
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown format %q (expected table, csv or json)\n", *format)
+		return exitUsage
+	}
+
+	world, err := readWorld(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFailure
+	}
+
+	var s *stats.Stats
+	if *converted {
+		result, err := converter.Convert(world)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error converting: %v\n", err)
+			return exitFailure
+		}
+		s = stats.FromSchematic(result.Schematic)
+	} else {
+		s = stats.FromWorld(world)
+	}
+
+	switch *format {
+	case "table":
+		err = s.WriteTable(os.Stdout)
+	case "csv":
+		err = s.WriteCSV(os.Stdout)
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(s)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing stats: %v\n", err)
+		return exitFailure
+	}
+	return exitOK
+}
@@ -0,0 +1,94 @@
+// Package structure exports converted builds as vanilla structure (.nbt)
+// files, as saved by structure blocks and loaded from datapacks.
+package structure
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"math"
+
+	"github.com/emmanuelvlad/slime2schem/internal/nbtenc"
+	"github.com/emmanuelvlad/slime2schem/schematic"
+)
+
+// MaxSize is the largest structure a structure block can save or load along
+// each axis. Larger volumes must be split into several files.
+const MaxSize = 48
+
+// Save encodes the schematic as a gzipped structure file. Every position is
+// written, air included, so placing the structure clears the volume the way a
+// structure block would. Save does not enforce MaxSize; split larger volumes
+// first (e.g. with converter.ConvertTiled).
+func Save(s *schematic.Schematic) ([]byte, error) {
+	nbtByPos := make(map[[3]int32]map[string]interface{}, len(s.BlockEntities))
+	for _, be := range s.BlockEntities {
+		data := make(map[string]interface{}, len(be.Data)+1)
+		for k, v := range be.Data {
+			data[k] = v
+		}
+		data["id"] = be.Id
+		nbtByPos[be.Pos] = data
+	}
+
+	var gzBuf bytes.Buffer
+	gzWriter := gzip.NewWriter(&gzBuf)
+	w := nbtenc.NewWriter(gzWriter)
+
+	w.BeginCompound("")
+	w.WriteInt("DataVersion", s.DataVersion)
+	w.WriteTag("size", []interface{}{int32(s.Width), int32(s.Height), int32(s.Length)})
+
+	palette := s.PaletteNames()
+	w.BeginList("palette", nbtenc.TagCompound, len(palette))
+	for _, state := range palette {
+		name, props := schematic.ParseBlockState(state)
+		w.WriteString("Name", name)
+		if len(props) > 0 {
+			w.WriteTag("Properties", props)
+		}
+		w.EndCompound()
+	}
+
+	w.BeginList("blocks", nbtenc.TagCompound, s.Width*s.Height*s.Length)
+	for y := 0; y < s.Height; y++ {
+		for z := 0; z < s.Length; z++ {
+			for x := 0; x < s.Width; x++ {
+				pos := [3]int32{int32(x), int32(y), int32(z)}
+				w.WriteTag("pos", []interface{}{pos[0], pos[1], pos[2]})
+				w.WriteInt("state", int32(s.PaletteIndexAt(x, y, z)))
+				if data, ok := nbtByPos[pos]; ok {
+					w.WriteTag("nbt", data)
+				}
+				w.EndCompound()
+			}
+		}
+	}
+
+	w.BeginList("entities", nbtenc.TagCompound, len(s.Entities))
+	for _, e := range s.Entities {
+		pos := []interface{}{e.Pos[0], e.Pos[1], e.Pos[2]}
+		data := make(map[string]interface{}, len(e.Data)+2)
+		for k, v := range e.Data {
+			data[k] = v
+		}
+		data["id"] = e.Id
+		data["Pos"] = pos
+		w.WriteTag("blockPos", []interface{}{
+			int32(math.Floor(e.Pos[0])), int32(math.Floor(e.Pos[1])), int32(math.Floor(e.Pos[2])),
+		})
+		w.WriteTag("nbt", data)
+		w.WriteTag("pos", pos)
+		w.EndCompound()
+	}
+
+	w.EndCompound() // root
+
+	if w.Err() != nil {
+		return nil, fmt.Errorf("encoding structure NBT: %w", w.Err())
+	}
+	if err := gzWriter.Close(); err != nil {
+		return nil, fmt.Errorf("closing gzip writer: %w", err)
+	}
+	return gzBuf.Bytes(), nil
+}
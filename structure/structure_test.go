@@ -0,0 +1,82 @@
+package structure
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/Tnze/go-mc/nbt"
+
+	"github.com/emmanuelvlad/slime2schem/schematic"
+)
+
+func TestSave(t *testing.T) {
+	s := schematic.NewSchematic(2, 1, 3, 3953)
+	s.SetBlock(0, 0, 0, "minecraft:stone")
+	s.SetBlock(1, 0, 2, "minecraft:chest[facing=north]")
+	s.BlockEntities = []schematic.BlockEntity{{Pos: [3]int32{1, 0, 2}, Id: "minecraft:chest", Data: map[string]interface{}{"Lock": "key"}}}
+	s.Entities = []schematic.Entity{{Pos: [3]float64{0.5, 0.2, -0.5}, Id: "minecraft:pig"}}
+
+	data, err := Save(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file struct {
+		DataVersion int32
+		Size        []int32 `nbt:"size"`
+		Palette     []struct {
+			Name       string
+			Properties map[string]string
+		} `nbt:"palette"`
+		Blocks []struct {
+			Pos   []int32                `nbt:"pos"`
+			State int32                  `nbt:"state"`
+			NBT   map[string]interface{} `nbt:"nbt"`
+		} `nbt:"blocks"`
+		Entities []struct {
+			BlockPos []int32                `nbt:"blockPos"`
+			Pos      []float64              `nbt:"pos"`
+			NBT      map[string]interface{} `nbt:"nbt"`
+		} `nbt:"entities"`
+	}
+	if _, err := nbt.NewDecoder(gz).Decode(&file); err != nil {
+		t.Fatal(err)
+	}
+
+	if file.DataVersion != 3953 || len(file.Size) != 3 || file.Size[0] != 2 || file.Size[1] != 1 || file.Size[2] != 3 {
+		t.Errorf("data version %d, size %v; want 3953, [2 1 3]", file.DataVersion, file.Size)
+	}
+	// Air is written too, so placing the structure clears the volume.
+	if len(file.Blocks) != 6 {
+		t.Fatalf("%d blocks, want all 6 positions", len(file.Blocks))
+	}
+	states := make(map[[3]int32]string)
+	for _, b := range file.Blocks {
+		p := file.Palette[b.State]
+		states[[3]int32{b.Pos[0], b.Pos[1], b.Pos[2]}] = schematic.BlockStateString(p.Name, p.Properties)
+		if b.NBT != nil && (b.NBT["id"] != "minecraft:chest" || b.NBT["Lock"] != "key" || b.Pos[0] != 1 || b.Pos[2] != 2) {
+			t.Errorf("block at %v has nbt %v", b.Pos, b.NBT)
+		}
+	}
+	for pos, want := range map[[3]int32]string{
+		{0, 0, 0}: "minecraft:stone",
+		{1, 0, 2}: "minecraft:chest[facing=north]",
+		{1, 0, 0}: "minecraft:air",
+	} {
+		if states[pos] != want {
+			t.Errorf("block at %v = %s, want %s", pos, states[pos], want)
+		}
+	}
+
+	if len(file.Entities) != 1 {
+		t.Fatalf("%d entities, want 1", len(file.Entities))
+	}
+	e := file.Entities[0]
+	if e.BlockPos[0] != 0 || e.BlockPos[1] != 0 || e.BlockPos[2] != -1 || e.NBT["id"] != "minecraft:pig" {
+		t.Errorf("entity at block %v with nbt %v, want a pig at block 0,0,-1", e.BlockPos, e.NBT)
+	}
+}
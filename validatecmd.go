@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/emmanuelvlad/slime2schem/slime"
+)
+
+// runValidate implements "slime2schem validate". It exits with 0 when the
+// world has no problems, 1 when it has some and 2 on error.
+func runValidate(args []string) int {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	jsonOut := fs.Bool("json", false, "Print the problems as JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: slime2schem validate [-json] <world.slime>\n")
+		fmt.Fprintf(os.Stderr, "\nChecks a world for duplicate chunks, block data that does not match its palette,\n")
+		fmt.Fprintf(os.Stderr, "malformed light, entities or block entities outside their chunk, and NBT that\n")
+		fmt.Fprintf(os.Stderr, "could not be decoded.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	world, err := readWorld(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitTrouble
+	}
+
+	problems := slime.Validate(world)
+	if *jsonOut {
+		if problems == nil {
+			problems = []slime.Problem{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(problems); err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding problems: %v\n", err)
+			return exitTrouble
+		}
+	} else {
+		for _, p := range problems {
+			fmt.Println(p)
+		}
+		fmt.Printf("%d chunks checked, %d problems\n", len(world.Chunks), len(problems))
+	}
+
+	if len(problems) > 0 {
+		return exitFailure
+	}
+	return exitOK
+}
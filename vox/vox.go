@@ -0,0 +1,273 @@
+// Package vox exports schematics as MagicaVoxel (.vox) files.
+//
+// Blocks become voxels coloured with their map colour (see
+// render.BlockColor); see-through blocks such as glass and torches are
+// left out. MagicaVoxel models are at most 256 voxels along each axis, so
+// larger schematics are split into several models placed side by side in
+// the file's scene graph.
+package vox
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image/color"
+	"sort"
+
+	"github.com/emmanuelvlad/slime2schem/render"
+	"github.com/emmanuelvlad/slime2schem/schematic"
+)
+
+// MaxModelSize is the largest model MagicaVoxel accepts along each axis.
+const MaxModelSize = 256
+
+// version is the .vox format version written; MagicaVoxel 0.99 and later
+// read the scene graph chunks in it.
+const version = 150
+
+// paletteSize is the number of usable palette entries; index 0 is empty.
+const paletteSize = 255
+
+// model is one piece of the schematic, at most MaxModelSize on each axis.
+type model struct {
+	origin [3]int // schematic coordinates of the piece's minimum corner
+	size   [3]int // schematic width, height, length
+	voxels []voxel
+}
+
+// voxel is a voxel in MagicaVoxel coordinates (Z up) within its model.
+type voxel struct {
+	x, y, z uint8
+	color   uint8 // palette index, 1-255
+}
+
+// Save encodes s as a .vox file. Schematic X, Y and Z map to MagicaVoxel
+// X, Z and -Y, so north stays north and the build stands upright.
+//
+// The palette holds the build's block colours, most used first; if there
+// are more than 255, the remaining ones use the nearest palette colour.
+func Save(s *schematic.Schematic) ([]byte, error) {
+	colors, counts := blockColors(s)
+	palette, index := buildPalette(colors, counts)
+
+	var models []model
+	for oy := 0; oy < s.Height; oy += MaxModelSize {
+		for oz := 0; oz < s.Length; oz += MaxModelSize {
+			for ox := 0; ox < s.Width; ox += MaxModelSize {
+				m := model{
+					origin: [3]int{ox, oy, oz},
+					size: [3]int{
+						min(MaxModelSize, s.Width-ox),
+						min(MaxModelSize, s.Height-oy),
+						min(MaxModelSize, s.Length-oz),
+					},
+				}
+				for y := 0; y < m.size[1]; y++ {
+					for z := 0; z < m.size[2]; z++ {
+						for x := 0; x < m.size[0]; x++ {
+							c := index[s.PaletteIndexAt(ox+x, oy+y, oz+z)]
+							if c == 0 {
+								continue
+							}
+							m.voxels = append(m.voxels, voxel{
+								x:     uint8(x),
+								y:     uint8(m.size[2] - 1 - z),
+								z:     uint8(y),
+								color: c,
+							})
+						}
+					}
+				}
+				if len(m.voxels) > 0 {
+					models = append(models, m)
+				}
+			}
+		}
+	}
+	if len(models) == 0 {
+		return nil, errors.New("schematic has no visible blocks")
+	}
+	return encode(s, models, palette), nil
+}
+
+// blockColors returns the colour of each schematic palette entry (zero
+// alpha for air and see-through blocks) and the number of blocks using it.
+func blockColors(s *schematic.Schematic) ([]color.NRGBA, []int) {
+	names := s.PaletteNames()
+	colors := make([]color.NRGBA, len(names))
+	for i, state := range names {
+		c, ok := render.BlockColor(state)
+		if !ok {
+			// Blocks missing from the colour table are drawn like stone.
+			c, _ = render.BlockColor("minecraft:stone")
+		}
+		colors[i] = c
+	}
+	counts := make([]int, len(names))
+	for y := 0; y < s.Height; y++ {
+		for z := 0; z < s.Length; z++ {
+			for x := 0; x < s.Width; x++ {
+				counts[s.PaletteIndexAt(x, y, z)]++
+			}
+		}
+	}
+	return colors, counts
+}
+
+// buildPalette picks up to 255 colours, most used first, and maps every
+// schematic palette entry to a .vox palette index (0 for nothing).
+func buildPalette(colors []color.NRGBA, counts []int) ([]color.NRGBA, []uint8) {
+	usage := make(map[color.NRGBA]int)
+	for i, c := range colors {
+		if c.A != 0 && counts[i] > 0 {
+			usage[c] += counts[i]
+		}
+	}
+	distinct := make([]color.NRGBA, 0, len(usage))
+	for c := range usage {
+		distinct = append(distinct, c)
+	}
+	sort.Slice(distinct, func(i, j int) bool {
+		a, b := distinct[i], distinct[j]
+		if usage[a] != usage[b] {
+			return usage[a] > usage[b]
+		}
+		return rgbKey(a) < rgbKey(b)
+	})
+	palette := distinct[:min(len(distinct), paletteSize)]
+
+	index := make([]uint8, len(colors))
+	for i, c := range colors {
+		if c.A == 0 || counts[i] == 0 {
+			continue
+		}
+		index[i] = uint8(nearest(palette, c) + 1)
+	}
+	return palette, index
+}
+
+func rgbKey(c color.NRGBA) uint32 {
+	return uint32(c.R)<<16 | uint32(c.G)<<8 | uint32(c.B)
+}
+
+// nearest returns the index of the palette colour closest to c, by
+// squared RGB distance weighted for perceived brightness.
+func nearest(palette []color.NRGBA, c color.NRGBA) int {
+	best, bestDist := 0, -1
+	for i, p := range palette {
+		dr, dg, db := int(p.R)-int(c.R), int(p.G)-int(c.G), int(p.B)-int(c.B)
+		dist := 2*dr*dr + 4*dg*dg + 3*db*db
+		if bestDist < 0 || dist < bestDist {
+			best, bestDist = i, dist
+		}
+	}
+	return best
+}
+
+// encode writes the MAIN chunk: every model's SIZE and XYZI, the scene
+// graph placing them, and the palette.
+func encode(s *schematic.Schematic, models []model, palette []color.NRGBA) []byte {
+	var children bytes.Buffer
+	for _, m := range models {
+		var size bytes.Buffer
+		binary.Write(&size, binary.LittleEndian, [3]int32{int32(m.size[0]), int32(m.size[2]), int32(m.size[1])})
+		writeChunk(&children, "SIZE", size.Bytes(), nil)
+
+		var xyzi bytes.Buffer
+		binary.Write(&xyzi, binary.LittleEndian, int32(len(m.voxels)))
+		for _, v := range m.voxels {
+			xyzi.Write([]byte{v.x, v.y, v.z, v.color})
+		}
+		writeChunk(&children, "XYZI", xyzi.Bytes(), nil)
+	}
+
+	// Scene graph: a root transform over a group holding one transform
+	// and shape per model. Node ids: 0 root, 1 group, then pairs.
+	groupChildren := make([]int32, len(models))
+	for i := range models {
+		groupChildren[i] = int32(2 + 2*i)
+	}
+	writeChunk(&children, "nTRN", transformNode(0, 1, -1, ""), nil)
+	writeChunk(&children, "nGRP", groupNode(1, groupChildren), nil)
+	for i, m := range models {
+		// A model's translation is its centre, rounded down, in the
+		// file's coordinates; the scene is centred on X and Y.
+		t := [3]int{
+			m.origin[0] + m.size[0]/2 - s.Width/2,
+			(s.Length - m.origin[2] - m.size[2]) + m.size[2]/2 - s.Length/2,
+			m.origin[1] + m.size[1]/2,
+		}
+		writeChunk(&children, "nTRN", transformNode(int32(2+2*i), int32(3+2*i), 0, fmt.Sprintf("%d %d %d", t[0], t[1], t[2])), nil)
+		writeChunk(&children, "nSHP", shapeNode(int32(3+2*i), int32(i)), nil)
+	}
+
+	var rgba bytes.Buffer
+	for i := 0; i < 256; i++ {
+		var c color.NRGBA
+		if i < len(palette) {
+			c = palette[i]
+		}
+		rgba.Write([]byte{c.R, c.G, c.B, 0xFF})
+	}
+	writeChunk(&children, "RGBA", rgba.Bytes(), nil)
+
+	var out bytes.Buffer
+	out.WriteString("VOX ")
+	binary.Write(&out, binary.LittleEndian, int32(version))
+	writeChunk(&out, "MAIN", nil, children.Bytes())
+	return out.Bytes()
+}
+
+// writeChunk writes a chunk header followed by its content and children.
+func writeChunk(w *bytes.Buffer, id string, content, children []byte) {
+	w.WriteString(id)
+	binary.Write(w, binary.LittleEndian, [2]int32{int32(len(content)), int32(len(children))})
+	w.Write(content)
+	w.Write(children)
+}
+
+// transformNode encodes an nTRN chunk with one frame, translated by t
+// ("x y z") unless t is empty.
+func transformNode(id, child, layer int32, t string) []byte {
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, id)
+	writeDict(&b, nil)
+	binary.Write(&b, binary.LittleEndian, [4]int32{child, -1, layer, 1})
+	var frame [][2]string
+	if t != "" {
+		frame = append(frame, [2]string{"_t", t})
+	}
+	writeDict(&b, frame)
+	return b.Bytes()
+}
+
+func groupNode(id int32, children []int32) []byte {
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, id)
+	writeDict(&b, nil)
+	binary.Write(&b, binary.LittleEndian, int32(len(children)))
+	binary.Write(&b, binary.LittleEndian, children)
+	return b.Bytes()
+}
+
+func shapeNode(id, modelID int32) []byte {
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, id)
+	writeDict(&b, nil)
+	binary.Write(&b, binary.LittleEndian, [2]int32{1, modelID})
+	writeDict(&b, nil)
+	return b.Bytes()
+}
+
+// writeDict writes a .vox DICT: a count, then length-prefixed keys and
+// values.
+func writeDict(w *bytes.Buffer, entries [][2]string) {
+	binary.Write(w, binary.LittleEndian, int32(len(entries)))
+	for _, e := range entries {
+		for _, s := range e {
+			binary.Write(w, binary.LittleEndian, int32(len(s)))
+			w.WriteString(s)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package vox
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/color"
+	"testing"
+
+	"github.com/emmanuelvlad/slime2schem/render"
+	"github.com/emmanuelvlad/slime2schem/schematic"
+)
+
+// voxFile is the part of a .vox file the tests look at.
+type voxFile struct {
+	sizes  [][3]int32
+	voxels [][]voxel
+	rgba   []byte
+}
+
+func parse(t *testing.T, data []byte) voxFile {
+	t.Helper()
+	if string(data[:4]) != "VOX " || binary.LittleEndian.Uint32(data[4:]) != version || string(data[8:12]) != "MAIN" {
+		t.Fatalf("header = %q", data[:12])
+	}
+	var f voxFile
+	r := bytes.NewReader(data[20:])
+	for r.Len() > 0 {
+		var id [4]byte
+		var sizes [2]int32
+		r.Read(id[:])
+		binary.Read(r, binary.LittleEndian, &sizes)
+		content := make([]byte, sizes[0])
+		r.Read(content)
+		switch string(id[:]) {
+		case "SIZE":
+			var size [3]int32
+			binary.Read(bytes.NewReader(content), binary.LittleEndian, &size)
+			f.sizes = append(f.sizes, size)
+		case "XYZI":
+			var vs []voxel
+			for i := 4; i+4 <= len(content); i += 4 {
+				vs = append(vs, voxel{content[i], content[i+1], content[i+2], content[i+3]})
+			}
+			f.voxels = append(f.voxels, vs)
+		case "RGBA":
+			f.rgba = content
+		}
+	}
+	return f
+}
+
+func TestSavePaletteIndexing(t *testing.T) {
+	s := schematic.NewSchematic(3, 1, 1, 3953)
+	s.SetBlock(0, 0, 0, "minecraft:stone")
+	s.SetBlock(1, 0, 0, "minecraft:stone")
+	s.SetBlock(2, 0, 0, "minecraft:oak_planks")
+	data, err := Save(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := parse(t, data)
+	if len(f.voxels) != 1 || len(f.voxels[0]) != 3 || len(f.rgba) != 256*4 {
+		t.Fatalf("%d models, rgba of %d bytes", len(f.voxels), len(f.rgba))
+	}
+	for _, v := range f.voxels[0] {
+		state := "minecraft:stone"
+		if v.x == 2 {
+			state = "minecraft:oak_planks"
+		}
+		want, _ := render.BlockColor(state)
+		// Voxel colour i is RGBA entry i-1.
+		got := f.rgba[(int(v.color)-1)*4:]
+		if got[0] != want.R || got[1] != want.G || got[2] != want.B {
+			t.Errorf("voxel at x %d has colour %d = %v, want %s %v", v.x, v.color, got[:3], state, want)
+		}
+	}
+	if f.voxels[0][0].color != 1 {
+		t.Errorf("stone has colour %d, want 1 as the most used", f.voxels[0][0].color)
+	}
+}
+
+func TestSaveSplitsLargeModels(t *testing.T) {
+	s := schematic.NewSchematic(MaxModelSize+1, 2, 1, 3953)
+	s.SetBlock(0, 0, 0, "minecraft:stone")
+	s.SetBlock(MaxModelSize, 1, 0, "minecraft:stone")
+	data, err := Save(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := parse(t, data)
+	// Sizes are X, Y, Z in MagicaVoxel's Z-up axes.
+	wantSizes := [][3]int32{{MaxModelSize, 1, 2}, {1, 1, 2}}
+	if len(f.sizes) != 2 || f.sizes[0] != wantSizes[0] || f.sizes[1] != wantSizes[1] {
+		t.Fatalf("model sizes = %v, want %v", f.sizes, wantSizes)
+	}
+	if len(f.voxels[1]) != 1 || f.voxels[1][0].x != 0 || f.voxels[1][0].z != 1 {
+		t.Errorf("second model voxels = %+v, want one at x 0, z 1", f.voxels[1])
+	}
+}
+
+func TestBuildPaletteOverflow(t *testing.T) {
+	// 256 distinct colours, the last one the least used.
+	colors := make([]color.NRGBA, paletteSize+1)
+	counts := make([]int, len(colors))
+	for i := range colors {
+		colors[i] = color.NRGBA{R: uint8(i), G: uint8(i), B: 10, A: 0xFF}
+		counts[i] = 1000 - i
+	}
+	palette, index := buildPalette(colors, counts)
+	if len(palette) != paletteSize {
+		t.Fatalf("palette has %d colours, want %d", len(palette), paletteSize)
+	}
+	for i := 0; i < paletteSize; i++ {
+		if int(index[i]) != i+1 {
+			t.Fatalf("colour %d has index %d, want %d", i, index[i], i+1)
+		}
+	}
+	// The extra colour falls back to its nearest neighbour.
+	if index[paletteSize] != paletteSize {
+		t.Errorf("extra colour has index %d, want %d", index[paletteSize], paletteSize)
+	}
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"crypto/sha256"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// runWatch implements "slime2schem watch".
+func runWatch(args []string) int {
+	flags := flag.NewFlagSet("watch", flag.ExitOnError)
+	outDir := flags.String("out", "", "Directory to write the converted worlds to, mirroring the watched folders (default: next to each world)")
+	debounce := flags.Duration("debounce", time.Second, "How long a world must go unmodified before it is converted")
+	var opts convertOptions
+	opts.register(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: slime2schem watch [-out DIR] [-debounce 1s] [convert flags] <dir>\n")
+		fmt.Fprintf(os.Stderr, "\nWatches a directory and its subdirectories, and converts .slime and .polar files\n")
+		fmt.Fprintf(os.Stderr, "when they change. Stop with Ctrl-C.\n\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 || *debounce < 0 {
+		flags.Usage()
+		return exitUsage
+	}
+	if err := opts.parse(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitUsage
+	}
+	root := flags.Arg(0)
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		fmt.Fprintf(os.Stderr, "Error: %s is not a directory\n", root)
+		return exitUsage
+	}
+
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFailure
+	}
+	defer fw.Close()
+
+	w := &watcher{
+		root:     root,
+		outDir:   *outDir,
+		opts:     &opts,
+		fw:       fw,
+		debounce: *debounce,
+		timers:   make(map[string]*time.Timer),
+		gens:     make(map[string]int),
+		hashes:   make(map[string][sha256.Size]byte),
+		ready:    make(chan pendingFile),
+		done:     make(chan struct{}),
+	}
+	defer close(w.done)
+	w.logf("Watching %s", root)
+	w.addDir(root, true)
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	for {
+		select {
+		case ev, ok := <-fw.Events:
+			if !ok {
+				return exitOK
+			}
+			w.handle(ev)
+		case err, ok := <-fw.Errors:
+			if !ok {
+				return exitOK
+			}
+			w.logf("Watch error: %v", err)
+		case p := <-w.ready:
+			if !w.latest(p) {
+				continue
+			}
+			delete(w.timers, p.file)
+			w.convert(p.file)
+		case <-interrupt:
+			w.logf("Stopped")
+			return exitOK
+		}
+	}
+}
+
+// watcher reconverts worlds in a directory tree when they change. Its
+// methods run on the goroutine of runWatch.
+type watcher struct {
+	root     string
+	outDir   string
+	opts     *convertOptions
+	fw       *fsnotify.Watcher
+	debounce time.Duration
+	// timers holds the pending conversion of each world written to within
+	// the debounce delay, and gens the number of the latest one.
+	timers map[string]*time.Timer
+	gens   map[string]int
+	// hashes holds the content hash of each world as last converted, so
+	// saves that do not change it are skipped.
+	hashes map[string][sha256.Size]byte
+	ready  chan pendingFile
+	// done is closed when runWatch returns, releasing timers still waiting
+	// to deliver.
+	done chan struct{}
+}
+
+// pendingFile is a world whose debounce delay has passed, sent by the
+// gen'th timer scheduled for it.
+type pendingFile struct {
+	file string
+	gen  int
+}
+
+func (w *watcher) logf(format string, args ...interface{}) {
+	fmt.Printf("%s %s\n", time.Now().Format("15:04:05"), fmt.Sprintf(format, args...))
+}
+
+// addDir watches dir and its subdirectories. Worlds already in them are
+// converted if their output is missing or older; at startup, other worlds
+// are only hashed, while in a directory created since, every world is new.
+func (w *watcher) addDir(dir string, startup bool) {
+	filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			w.logf("Error: %v", err)
+			return nil
+		}
+		if d.IsDir() {
+			if err := w.fw.Add(p); err != nil {
+				w.logf("Error watching %s: %v", p, err)
+			}
+			return nil
+		}
+		if !isWorldFile(p) {
+			return nil
+		}
+		if startup && w.upToDate(p) {
+			if sum, err := hashFile(p); err == nil {
+				w.hashes[p] = sum
+			}
+			return nil
+		}
+		w.schedule(p)
+		return nil
+	})
+}
+
+// upToDate reports whether the output of file, or the manifest of its
+// tiles, was written after file was last modified.
+func (w *watcher) upToDate(file string) bool {
+	in, err := os.Stat(file)
+	if err != nil {
+		return false
+	}
+	out := w.outputPath(file)
+	for _, p := range []string{out, strings.TrimSuffix(out, filepath.Ext(out)) + ".manifest.json"} {
+		if written, err := os.Stat(p); err == nil && !written.ModTime().Before(in.ModTime()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *watcher) handle(ev fsnotify.Event) {
+	switch {
+	case ev.Has(fsnotify.Create):
+		if info, err := os.Stat(ev.Name); err == nil && info.IsDir() {
+			w.addDir(ev.Name, false)
+			return
+		}
+		if isWorldFile(ev.Name) {
+			w.schedule(ev.Name)
+		}
+	case ev.Has(fsnotify.Write):
+		if isWorldFile(ev.Name) {
+			w.schedule(ev.Name)
+		}
+	case ev.Has(fsnotify.Remove), ev.Has(fsnotify.Rename):
+		// A renamed file shows up again as a Create under its new name.
+		if t, ok := w.timers[ev.Name]; ok {
+			t.Stop()
+			delete(w.timers, ev.Name)
+			w.gens[ev.Name]++
+		}
+		delete(w.hashes, ev.Name)
+	}
+}
+
+// schedule converts file once it has gone unmodified for the debounce
+// delay, replacing the conversion already pending, if any.
+func (w *watcher) schedule(file string) {
+	if t, ok := w.timers[file]; ok {
+		t.Stop()
+	}
+	w.gens[file]++
+	p := pendingFile{file, w.gens[file]}
+	w.timers[file] = time.AfterFunc(w.debounce, func() {
+		select {
+		case w.ready <- p:
+		case <-w.done:
+		}
+	})
+}
+
+// latest reports whether p comes from the timer last scheduled for its
+// file. A timer that fired before being replaced or cancelled may still
+// deliver; only the latest one counts.
+func (w *watcher) latest(p pendingFile) bool {
+	return p.gen == w.gens[p.file]
+}
+
+func (w *watcher) convert(file string) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			w.logf("FAIL  %s: %v", file, err)
+		}
+		return
+	}
+	sum := sha256.Sum256(data)
+	if prev, ok := w.hashes[file]; ok && prev == sum {
+		w.logf("skip  %s (unchanged)", file)
+		return
+	}
+
+	start := time.Now()
+	out := w.outputPath(file)
+	err = func() error {
+		world, err := parseWorld(file, data)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+			return err
+		}
+		return convertWorld(world, out, "", w.opts, io.Discard)
+	}()
+	if err != nil {
+		// The hash is not recorded, so saving the same content again
+		// retries, e.g. after fixing the output directory.
+		w.logf("FAIL  %s: %v", file, err)
+		return
+	}
+	w.hashes[file] = sum
+	w.logf("ok    %s -> %s (%.1fs)", file, out, time.Since(start).Seconds())
+}
+
+// outputPath returns where file is converted to: under the output
+// directory, mirroring its place in the watched tree, or next to it.
+func (w *watcher) outputPath(file string) string {
+	if w.outDir == "" {
+		return w.opts.outputPath(file)
+	}
+	rel := relPath(w.root, file)
+	return filepath.Join(w.outDir, strings.TrimSuffix(rel, filepath.Ext(rel))+w.opts.format.ext)
+}
+
+func hashFile(file string) ([sha256.Size]byte, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(data), nil
+}
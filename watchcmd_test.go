@@ -0,0 +1,57 @@
+package main
+
+import (
+	"crypto/sha256"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestWatchDebounceGenerations(t *testing.T) {
+	// With no debounce delay, each timer fires at once and waits to deliver,
+	// like a timer that fired just before being replaced.
+	w := &watcher{
+		timers: make(map[string]*time.Timer),
+		gens:   make(map[string]int),
+		hashes: make(map[string][sha256.Size]byte),
+		ready:  make(chan pendingFile),
+		done:   make(chan struct{}),
+	}
+	defer close(w.done)
+	receive := func() pendingFile {
+		t.Helper()
+		select {
+		case p := <-w.ready:
+			return p
+		case <-time.After(5 * time.Second):
+			t.Fatal("no timer delivered")
+			return pendingFile{}
+		}
+	}
+
+	w.schedule("a.slime")
+	first := receive()
+	w.schedule("a.slime")
+	second := receive()
+	if w.latest(first) {
+		t.Errorf("replaced timer %+v counted as latest", first)
+	}
+	if !w.latest(second) {
+		t.Errorf("latest timer %+v not counted", second)
+	}
+
+	// Other files keep their own generations.
+	w.schedule("b.slime")
+	if other := receive(); !w.latest(other) || !w.latest(second) {
+		t.Errorf("b.slime timer %+v interfered with a.slime", other)
+	}
+
+	// Removing a file cancels its pending conversion.
+	w.schedule("a.slime")
+	pending := receive()
+	w.handle(fsnotify.Event{Name: "a.slime", Op: fsnotify.Remove})
+	if w.latest(pending) {
+		t.Errorf("timer %+v of a removed file counted as latest", pending)
+	}
+}